@@ -0,0 +1,145 @@
+// Copyright 2020 Stephen Buckler. All rights reserved.
+// Use of this source code is governed by a MIT license
+// that can be found in the LICENSE file.
+
+package clapr
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+)
+
+/*
+LoadAliases reads an alias table from a file for use with WithAliases.
+Each line of the file defines one alias as "name = expansion". Blank
+lines and lines starting with '#' or ';' are ignored.
+*/
+func LoadAliases(path string) (map[string]string, error) {
+	f, err := os.Open(path)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer f.Close()
+
+	aliases := map[string]string{}
+	scanner := bufio.NewScanner(f)
+
+	for ln := 1; scanner.Scan(); ln++ {
+		line := strings.TrimSpace(scanner.Text())
+
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, ";") {
+			continue
+		}
+
+		parts := strings.SplitN(line, "=", 2)
+		name := strings.TrimSpace(parts[0])
+
+		if len(parts) != 2 || name == "" || strings.ContainsAny(name, " \t") {
+			return nil, fmt.Errorf("invalid alias definition on line %d: %s", ln, line)
+		}
+
+		aliases[name] = strings.TrimSpace(parts[1])
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return aliases, nil
+}
+
+func (r *runner) expandAliases(argv []string) ([]string, int, error) {
+	var chain []string
+	expanded := 0
+
+	for len(argv) > 0 {
+		expansion, ok := r.aliases[argv[0]]
+
+		if !ok || r.isCommand(argv[0]) {
+			break
+		}
+
+		for _, name := range chain {
+			if name == argv[0] {
+				return nil, 0, fmt.Errorf("recursive alias expansion: %s -> %s", strings.Join(chain, " -> "), argv[0])
+			}
+		}
+
+		words, err := splitWords(expansion)
+
+		if err != nil {
+			return nil, 0, fmt.Errorf("invalid alias %s: %v", argv[0], err)
+		}
+
+		if expanded > 0 {
+			expanded--
+		}
+
+		chain = append(chain, argv[0])
+		argv = append(words, argv[1:]...)
+		expanded += len(words)
+	}
+
+	return argv, expanded, nil
+}
+
+func (r *runner) isCommand(arg string) bool {
+	idx := newCmdIndex(r.root)
+
+	if _, ok := idx.sub(arg); ok {
+		return true
+	}
+
+	return r.prefix && len(idx.matchPrefix(arg)) == 1
+}
+
+func splitWords(s string) ([]string, error) {
+	var words []string
+	var word strings.Builder
+	var quote rune
+	inword := false
+	escaped := false
+
+	for _, ch := range s {
+		switch {
+		case escaped:
+			if quote == '"' && !strings.ContainsRune("\"\\$`", ch) {
+				word.WriteRune('\\')
+			}
+
+			word.WriteRune(ch)
+			escaped = false
+		case ch == '\\' && quote != '\'':
+			escaped, inword = true, true
+		case quote != 0 && ch == quote:
+			quote = 0
+		case quote != 0:
+			word.WriteRune(ch)
+		case ch == '\'' || ch == '"':
+			quote, inword = ch, true
+		case ch == ' ' || ch == '\t' || ch == '\n':
+			if inword {
+				words = append(words, word.String())
+				word.Reset()
+				inword = false
+			}
+		default:
+			word.WriteRune(ch)
+			inword = true
+		}
+	}
+
+	if escaped || quote != 0 {
+		return nil, fmt.Errorf("unterminated quote or escape in: %s", s)
+	}
+
+	if inword {
+		words = append(words, word.String())
+	}
+
+	return words, nil
+}
@@ -0,0 +1,157 @@
+// Copyright 2020 Stephen Buckler. All rights reserved.
+// Use of this source code is governed by a MIT license
+// that can be found in the LICENSE file.
+
+package clapr_test
+
+import (
+	"context"
+	"github.com/sebuckler/clapr"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+type testaliasfn func(t *testing.T, name string)
+
+func TestRunner_Run_Aliases(t *testing.T) {
+	testCases := map[string]testaliasfn{
+		"should expand alias":                shouldExpandAlias,
+		"should expand quoted alias":         shouldExpandQuotedAlias,
+		"should expand nested alias":         shouldExpandNestedAlias,
+		"should run command over alias":      shouldRunCmdOverAlias,
+		"should err when alias recursive":    shouldErrRecursiveAlias,
+		"should err when alias unterminated": shouldErrUnterminatedAlias,
+		"should load aliases from file":      shouldLoadAliases,
+		"should err when alias file invalid": shouldErrLoadAliases,
+	}
+
+	for name, test := range testCases {
+		test(t, name)
+	}
+}
+
+func shouldExpandAlias(t *testing.T, name string) {
+	runAliasTest(t, name, map[string]string{"co": "checkout --quiet"}, []string{"test", "co", "--", "foo"}, []string{"foo"})
+}
+
+func shouldExpandQuotedAlias(t *testing.T, name string) {
+	aliases := map[string]string{"co": `checkout --quiet -- "foo bar" 'baz \qux' a\ b "\"c\""`}
+	runAliasTest(t, name, aliases, []string{"test", "co", "last"}, []string{"foo bar", `baz \qux`, "a b", `"c"`, "last"})
+}
+
+func shouldExpandNestedAlias(t *testing.T, name string) {
+	aliases := map[string]string{"c": "co --", "co": "checkout --quiet"}
+	runAliasTest(t, name, aliases, []string{"test", "c", "foo"}, []string{"foo"})
+}
+
+func shouldRunCmdOverAlias(t *testing.T, name string) {
+	os.Args = []string{"test", "checkout", "--quiet"}
+	ran := false
+	cmd := &clapr.Command{}
+	cmd.AddSubcommand(&clapr.Command{
+		Args: []*clapr.Arg{{Name: "quiet"}},
+		Name: "checkout",
+		Run: func(context.Context, []string) {
+			ran = true
+		},
+	})
+	runner := clapr.NewRunner(cmd, clapr.GNU, clapr.WithAliases(map[string]string{"checkout": "bogus"}))
+
+	if err := runner.Run(context.Background()); err != nil || !ran {
+		t.Fail()
+		t.Logf("%s: did not run command: %v", name, err)
+	}
+}
+
+func shouldErrRecursiveAlias(t *testing.T, name string) {
+	os.Args = []string{"test", "a"}
+	runner := clapr.NewRunner(&clapr.Command{}, clapr.GNU, clapr.WithAliases(map[string]string{"a": "b", "b": "a"}))
+
+	if err := runner.Run(context.Background()); err == nil {
+		t.Fail()
+		t.Logf("%s: did not error", name)
+	}
+}
+
+func shouldErrUnterminatedAlias(t *testing.T, name string) {
+	os.Args = []string{"test", "a"}
+	runner := clapr.NewRunner(&clapr.Command{}, clapr.GNU, clapr.WithAliases(map[string]string{"a": `-- "foo`}))
+
+	if err := runner.Run(context.Background()); err == nil {
+		t.Fail()
+		t.Logf("%s: did not error", name)
+	}
+}
+
+func shouldLoadAliases(t *testing.T, name string) {
+	path := writeAliasFile(t, "# aliases\n\nco = checkout --quiet\n; comment\nst=status\n")
+	defer os.RemoveAll(filepath.Dir(path))
+	expect := map[string]string{"co": "checkout --quiet", "st": "status"}
+	aliases, err := clapr.LoadAliases(path)
+
+	if err != nil {
+		t.Fail()
+		t.Logf("%s: errored: %v", name, err)
+	}
+
+	if !reflect.DeepEqual(aliases, expect) {
+		t.Fail()
+		t.Errorf("%s: expected: %v got: %v", name, expect, aliases)
+	}
+}
+
+func shouldErrLoadAliases(t *testing.T, name string) {
+	path := writeAliasFile(t, "co checkout\n")
+	defer os.RemoveAll(filepath.Dir(path))
+
+	if _, err := clapr.LoadAliases(path); err == nil {
+		t.Fail()
+		t.Logf("%s: did not error", name)
+	}
+}
+
+func runAliasTest(t *testing.T, name string, aliases map[string]string, args []string, expect []string) {
+	os.Args = args
+	quiet := false
+	var got []string
+	cmd := &clapr.Command{}
+	cmd.AddSubcommand(&clapr.Command{
+		Args: []*clapr.Arg{{Binder: clapr.NewBoolArgBinder(&quiet), Name: "quiet"}},
+		Name: "checkout",
+		Run: func(_ context.Context, operands []string) {
+			got = operands
+		},
+	})
+	runner := clapr.NewRunner(cmd, clapr.GNU, clapr.WithAliases(aliases))
+
+	if err := runner.Run(context.Background()); err != nil {
+		t.Fail()
+		t.Logf("%s: errored: %v", name, err)
+
+		return
+	}
+
+	if !quiet || !reflect.DeepEqual(got, expect) {
+		t.Fail()
+		t.Errorf("%s: expected: %v got: %v, quiet: %t", name, expect, got, quiet)
+	}
+}
+
+func writeAliasFile(t *testing.T, content string) string {
+	dir, err := ioutil.TempDir("", "clapr")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(dir, "aliases")
+
+	if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	return path
+}
@@ -8,6 +8,7 @@ import (
 	"fmt"
 	"strconv"
 	"strings"
+	"time"
 )
 
 /*
@@ -15,16 +16,23 @@ An Arg is a single argument definition for a command.
 */
 type Arg struct {
 	Binder     ArgBinder // For parser to bind values
+	Hidden     bool      // Left out of help text output
 	IsHelp     bool      // ErrHelp parser error when argument parsed
 	Name       string    // Long name of argument and help text display value
 	ShortName  rune      // Single character argument name
+	Persistent bool      // Also accepted after any subcommand of the defining command
 	Repeatable bool      // Allows argument to be parsed multiple times
 	Required   bool      // Parser error if no value supplied for argument
 	Usage      string    // Short description for help text output
 }
 
 /*
-ArgBinder is for assigning argument values during parsing.
+ArgBinder is for assigning argument values during parsing. Binding
+only happens once every parsed command's arguments are valid. If a
+later argument fails to bind, values already assigned by binders that
+implement SnapshotBinder, including all provided binders, are restored
+to what they were before parsing. Values assigned by other binders are
+left as they are.
 */
 type ArgBinder interface {
 	// Bind attempts to assign a parsed value to a parsed argument and
@@ -32,10 +40,27 @@ type ArgBinder interface {
 	Bind(arg string, val string) error
 }
 
+/*
+SnapshotBinder is an ArgBinder that can restore its value when binding
+fails part way through. Custom binders should implement it to take part
+in the rollback.
+*/
+type SnapshotBinder interface {
+	ArgBinder
+
+	// Snapshot captures the currently bound value and returns a
+	// function that restores it.
+	Snapshot() func()
+}
+
 type boolArgBinder struct {
 	val *bool
 }
 
+type durationBinder struct {
+	val *time.Duration
+}
+
 type float64Binder struct {
 	val *float64
 }
@@ -103,6 +128,41 @@ func (b *boolArgBinder) Bind(arg string, val string) error {
 	return nil
 }
 
+func (b *boolArgBinder) Snapshot() func() {
+	prev := *(b.val)
+
+	return func() { *(b.val) = prev }
+}
+
+/*
+NewDurationArgBinder returns an ArgBinder for time.Duration arguments.
+The Bind method will not attempt to bind a value if none is provided
+on the command line. Bind will error if value provided cannot parse as
+a time.Duration, such as "300ms" or "1m30s".
+*/
+func NewDurationArgBinder(p *time.Duration) ArgBinder {
+	return &durationBinder{val: p}
+}
+
+func (b *durationBinder) Bind(arg string, val string) error {
+	if val == "" {
+		return nil
+	}
+
+	if d, err := time.ParseDuration(val); err != nil {
+		return fmt.Errorf("invalid option-argument: '%s' for option: %s", val, arg)
+	} else {
+		*(b.val) = d
+		return nil
+	}
+}
+
+func (b *durationBinder) Snapshot() func() {
+	prev := *(b.val)
+
+	return func() { *(b.val) = prev }
+}
+
 /*
 NewFloat64ArgBinder returns an ArgBinder for float64 arguments. The
 Bind method will not attempt to bind a value if none is provided on
@@ -126,6 +186,12 @@ func (b *float64Binder) Bind(arg string, val string) error {
 	}
 }
 
+func (b *float64Binder) Snapshot() func() {
+	prev := *(b.val)
+
+	return func() { *(b.val) = prev }
+}
+
 /*
 NewFloat64ListArgBinder returns an ArgBinder for []float64 arguments.
 The Bind method will not attempt to bind a value if none is provided
@@ -156,6 +222,12 @@ func (b *float64ListBinder) Bind(arg string, val string) error {
 	return nil
 }
 
+func (b *float64ListBinder) Snapshot() func() {
+	prev := *(b.val)
+
+	return func() { *(b.val) = prev }
+}
+
 /*
 NewIntArgBinder returns an ArgBinder for int arguments. The Bind method
 will not attempt to bind a value if none is provided on the command
@@ -178,6 +250,12 @@ func (b *intBinder) Bind(arg string, val string) error {
 	}
 }
 
+func (b *intBinder) Snapshot() func() {
+	prev := *(b.val)
+
+	return func() { *(b.val) = prev }
+}
+
 /*
 NewIntListArgBinder returns an ArgBinder for []int arguments. The Bind
 method will not attempt to bind a value if none is provided on the
@@ -208,6 +286,12 @@ func (b *intListBinder) Bind(arg string, val string) error {
 	return nil
 }
 
+func (b *intListBinder) Snapshot() func() {
+	prev := *(b.val)
+
+	return func() { *(b.val) = prev }
+}
+
 /*
 NewInt64ArgBinder returns an ArgBinder for int64 arguments. The Bind
 method will not attempt to bind a value if none is provided on the
@@ -231,6 +315,12 @@ func (b *int64Binder) Bind(arg string, val string) error {
 	}
 }
 
+func (b *int64Binder) Snapshot() func() {
+	prev := *(b.val)
+
+	return func() { *(b.val) = prev }
+}
+
 /*
 NewInt64ListArgBinder returns an ArgBinder for []int64 arguments. The
 Bind method will not attempt to bind a value if none is provided on the
@@ -261,6 +351,12 @@ func (b *int64ListBinder) Bind(arg string, val string) error {
 	return nil
 }
 
+func (b *int64ListBinder) Snapshot() func() {
+	prev := *(b.val)
+
+	return func() { *(b.val) = prev }
+}
+
 /*
 NewStringArgBinder returns an ArgBinder for string arguments. The Bind
 method will not attempt to bind a value if none is provided on the
@@ -281,6 +377,12 @@ func (b *stringBinder) Bind(_ string, val string) error {
 	return nil
 }
 
+func (b *stringBinder) Snapshot() func() {
+	prev := *(b.val)
+
+	return func() { *(b.val) = prev }
+}
+
 /*
 NewStringListArgBinder returns an ArgBinder for []string arguments. The
 Bind method will not attempt to bind a value if none is provided on the
@@ -307,6 +409,12 @@ func (b *stringListBinder) Bind(_ string, val string) error {
 	return nil
 }
 
+func (b *stringListBinder) Snapshot() func() {
+	prev := *(b.val)
+
+	return func() { *(b.val) = prev }
+}
+
 /*
 NewUintArgBinder returns an ArgBinder for uint arguments. The Bind
 method will not attempt to bind a value if none is provided on the
@@ -329,6 +437,12 @@ func (b *uintBinder) Bind(arg string, val string) error {
 	}
 }
 
+func (b *uintBinder) Snapshot() func() {
+	prev := *(b.val)
+
+	return func() { *(b.val) = prev }
+}
+
 /*
 NewUintListArgBinder returns an ArgBinder for []uint arguments. The
 Bind method will not attempt to bind a value if none is provided on the
@@ -359,6 +473,12 @@ func (b *uintListBinder) Bind(arg string, val string) error {
 	return nil
 }
 
+func (b *uintListBinder) Snapshot() func() {
+	prev := *(b.val)
+
+	return func() { *(b.val) = prev }
+}
+
 /*
 NewUint64ArgBinder returns an ArgBinder for uint64 arguments. The Bind
 method will not attempt to bind a value if none is provided on the
@@ -382,6 +502,12 @@ func (b *uint64Binder) Bind(arg string, val string) error {
 	}
 }
 
+func (b *uint64Binder) Snapshot() func() {
+	prev := *(b.val)
+
+	return func() { *(b.val) = prev }
+}
+
 /*
 NewUint64ListArgBinder returns an ArgBinder for []uint64 arguments. The
 Bind method will not attempt to bind a value if none is provided on the
@@ -411,3 +537,9 @@ func (b *uint64ListBinder) Bind(arg string, val string) error {
 
 	return nil
 }
+
+func (b *uint64ListBinder) Snapshot() func() {
+	prev := *(b.val)
+
+	return func() { *(b.val) = prev }
+}
@@ -8,6 +8,7 @@ import (
 	"github.com/sebuckler/clapr"
 	"reflect"
 	"testing"
+	"time"
 )
 
 type testargfn func(t *testing.T, name string)
@@ -23,6 +24,18 @@ func TestBoolArgBinder_Bind(t *testing.T) {
 	}
 }
 
+func TestDurationBinder_Bind(t *testing.T) {
+	testcases := map[string]testargfn{
+		"should bind value":                         shouldBindDuration,
+		"should err when cast fails":                shouldErrDuration,
+		"should not bind when opt-arg not provided": shouldNotBindDuration,
+	}
+
+	for name, test := range testcases {
+		test(t, name)
+	}
+}
+
 func TestFloat64Binder_Bind(t *testing.T) {
 	testcases := map[string]testargfn{
 		"should bind value":                         shouldBindFloat64,
@@ -187,6 +200,55 @@ func shouldErrBool(t *testing.T, name string) {
 	}
 }
 
+func shouldBindDuration(t *testing.T, name string) {
+	val := time.Second
+	expect := 90 * time.Second
+	binder := clapr.NewDurationArgBinder(&val)
+	err := binder.Bind("-b", "1m30s")
+
+	if err != nil {
+		t.Fail()
+		t.Logf("%s: %s %v", name, "errored:", err)
+
+		return
+	}
+
+	if val != expect {
+		t.Fail()
+		t.Logf("%s: %s %v %s %v", name, "expected:", expect, "got:", val)
+	}
+}
+
+func shouldErrDuration(t *testing.T, name string) {
+	val := time.Second
+	binder := clapr.NewDurationArgBinder(&val)
+	err := binder.Bind("-b", "a")
+
+	if err == nil {
+		t.Fail()
+		t.Logf("%s: did not error", name)
+	}
+}
+
+func shouldNotBindDuration(t *testing.T, name string) {
+	val := time.Second
+	expect := time.Second
+	binder := clapr.NewDurationArgBinder(&val)
+	err := binder.Bind("-b", "")
+
+	if err != nil {
+		t.Fail()
+		t.Logf("%s: %s %v", name, "errored:", err)
+
+		return
+	}
+
+	if val != expect {
+		t.Fail()
+		t.Logf("%s: %s %v %s %v", name, "expected:", expect, "got:", val)
+	}
+}
+
 func shouldBindFloat64(t *testing.T, name string) {
 	val := float64(1)
 	expect := float64(2)
@@ -0,0 +1,76 @@
+// Copyright 2020 Stephen Buckler. All rights reserved.
+// Use of this source code is governed by a MIT license
+// that can be found in the LICENSE file.
+
+package clapr
+
+func (p *parser) newBuiltinArgs() []*Arg {
+	var args []*Arg
+
+	if p.versionflag || p.root.Version != "" {
+		p.versionarg = &Arg{
+			Binder:    NewBoolArgBinder(new(bool)),
+			Name:      "version",
+			ShortName: 'V',
+			Usage:     "display version information",
+		}
+		args = append(args, p.versionarg)
+	}
+
+	if p.explainflag && p.syntax == GNU {
+		p.explainarg = &Arg{
+			Binder:     NewBoolArgBinder(new(bool)),
+			Name:       "explain",
+			Persistent: true,
+			Usage:      "show what would run without running anything",
+		}
+		args = append(args, p.explainarg)
+	}
+
+	if p.profiling && p.syntax == GNU {
+		args = append(args, p.newProfilingArgs()...)
+	}
+
+	if p.timeoutflag && p.syntax == GNU {
+		args = append(args, &Arg{
+			Binder:     NewDurationArgBinder(&p.timeout),
+			Name:       "timeout",
+			Persistent: true,
+			Usage:      "limit how long each command may run, such as 30s or 5m",
+		})
+	}
+
+	return args
+}
+
+func (p *parser) builtinArgs(cmd *Command) []*Arg {
+	var args []*Arg
+
+	for _, a := range p.builtins {
+		if a.Persistent || cmd == p.root {
+			args = append(args, a)
+		}
+	}
+
+	return args
+}
+
+func (p *parser) builtinIndex(cmd *Command) *cmdIndex {
+	return newArgIndex(p.builtinArgs(cmd))
+}
+
+func (ctx *parsedArgContext) longArg(name string) (*Arg, bool) {
+	if a, ok := ctx.index.longArg(name); ok {
+		return a, true
+	}
+
+	return ctx.builtins.longArg(name)
+}
+
+func (ctx *parsedArgContext) shortArg(name rune) (*Arg, bool) {
+	if a, ok := ctx.index.shortArg(name); ok {
+		return a, true
+	}
+
+	return ctx.builtins.shortArg(name)
+}
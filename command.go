@@ -6,20 +6,70 @@ package clapr
 
 import (
 	"context"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
 )
 
 /*
 A Command defines utility functionality. Argument definitions, run
-functions, and subcommands are all defined on a Command.
+functions, and subcommands are all defined on a Command. Parsing never
+modifies a Command, so a tree may be shared between runners once it
+has been built. Option and subcommand lookups are worked out again on
+every parse, so changes made to a Command are seen by the next run.
 */
 type Command struct {
-	Args    []*Arg                                       // Argument definitions to be used as command flags
-	Name    string                                       // GNU long-option name as well as help text identifier
-	Run     func(ctx context.Context, operands []string) // Function to execute when command is parsed
-	Usage   string                                       // Description for intended usage in help text output
-	helper  Helper
-	parent  *Command
-	subcmds []*Command
+	Aliases           []string                                           // Alternative names the command can be called by
+	Args              []*Arg                                             // Argument definitions to be used as command flags
+	Middleware        []Middleware                                       // Wraps the run function, the first is outermost
+	Name              string                                             // GNU long-option name as well as help text identifier
+	Operands          OperandValidator                                   // Checks parsed operands before any command runs
+	Passthrough       bool                                               // Skips running when a subcommand is parsed under RunExplicit
+	PersistentPostRun func(ctx context.Context, operands []string) error // Runs after the command and its parsed descendants
+	PersistentPreRun  func(ctx context.Context, operands []string) error // Runs before the command and its parsed descendants
+	PostRun           func(ctx context.Context, operands []string) error // Runs after the command, even if its run fails
+	PreRun            func(ctx context.Context, operands []string) error // Runs before the command, its error skips the run
+	Run               func(ctx context.Context, operands []string)       // Function to execute when command is parsed
+	RunE              func(ctx context.Context, operands []string) error // Used instead of Run when set, its error stops execution
+	Timeout           time.Duration                                      // Cancels the run function's context after this long, zero means no limit
+	Usage             string                                             // Description for intended usage in help text output
+	Version           string                                             // Displayed by the built-in version option when set on the root command
+	helper            Helper
+	parent            *Command
+	subcmds           []*subcommand
+}
+
+type subcommand struct {
+	cmd   *Command
+	fn    func() *Command
+	name  string
+	once  sync.Once
+	usage string
+}
+
+type cmdIndex struct {
+	argfinds int
+	args     []*Arg
+	bylong   map[string]*Arg
+	byname   map[string]*subcommand
+	byshort  map[rune]*Arg
+	cmd      *Command
+	subfinds int
+}
+
+// indexAfter is how many lookups on a command are answered by scanning
+// before lookup tables are built for it. A single parse usually only
+// looks up a few names, which is cheaper than building the tables.
+const indexAfter = 8
+
+var defaultHelpArg = &Arg{
+	Binder:     NewBoolArgBinder(new(bool)),
+	IsHelp:     true,
+	Name:       "help",
+	Repeatable: true,
+	ShortName:  'h',
+	Usage:      "display usage information for this Command",
 }
 
 /*
@@ -40,39 +90,385 @@ is also parsed.
 */
 func (c *Command) AddSubcommand(cmd ...*Command) {
 	if c.subcmds == nil {
-		c.subcmds = []*Command{}
+		c.subcmds = []*subcommand{}
 	}
 
 	for _, sub := range cmd {
-		configureArgs(sub)
 		sub.parent = c
-		c.subcmds = append(c.subcmds, sub)
+		c.subcmds = append(c.subcmds, &subcommand{cmd: sub, name: sub.Name})
+	}
+}
+
+/*
+AddLazySubcommand adds a subcommand that is only constructed when it is
+needed, such as when its name is parsed. The function is called at most
+once and the command it returns is used as the subcommand called name.
+Help text lists a lazy subcommand by name with the given usage, without
+constructing it. Until it is constructed, a lazy subcommand can only be
+matched by name, not by its aliases.
+*/
+func (c *Command) AddLazySubcommand(name string, usage string, fn func() *Command) {
+	c.subcmds = append(c.subcmds, &subcommand{fn: fn, name: name, usage: usage})
+}
+
+/*
+Find returns the descendant reached by following the given subcommand
+names or aliases from the command, or nil if there is no such command.
+Only the lazy subcommands along the path are constructed. Find with no
+names returns the command itself.
+*/
+func (c *Command) Find(names ...string) *Command {
+	cmd := c
+
+	for _, name := range names {
+		var next *Command
+
+		for _, sub := range cmd.subcmds {
+			if containsString(sub.names(), name) {
+				next = sub.resolve(cmd)
+
+				break
+			}
+		}
+
+		if next == nil {
+			return nil
+		}
+
+		cmd = next
+	}
+
+	return cmd
+}
+
+/*
+Parent returns the command the command was added to as a subcommand,
+or nil if it is a root command.
+*/
+func (c *Command) Parent() *Command {
+	return c.parent
+}
+
+/*
+Path returns the names of the command and its parents from the root
+down, separated by spaces, such as "tool db migrate".
+*/
+func (c *Command) Path() string {
+	var names []string
+
+	for cmd := c; cmd != nil; cmd = cmd.parent {
+		if cmd.Name != "" {
+			names = append([]string{cmd.Name}, names...)
+		}
+	}
+
+	return strings.Join(names, " ")
+}
+
+/*
+Subcommands returns the subcommands added to the command, in the order
+they were added. Any lazy subcommands are constructed. Changing the
+returned slice does not change the command.
+*/
+func (c *Command) Subcommands() []*Command {
+	var cmds []*Command
+
+	for _, sub := range c.subcmds {
+		if cmd := sub.resolve(c); cmd != nil {
+			cmds = append(cmds, cmd)
+		}
 	}
+
+	return cmds
+}
+
+/*
+Walk calls fn for the command and each of its descendants, parents
+before their subcommands. If fn returns an error, Walk stops and
+returns it.
+*/
+func Walk(cmd *Command, fn func(cmd *Command) error) error {
+	if err := fn(cmd); err != nil {
+		return err
+	}
+
+	for _, sub := range cmd.Subcommands() {
+		if err := Walk(sub, fn); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (c *Command) args() []*Arg {
+	if c.hasHelpArg() {
+		return c.Args
+	}
+
+	return append(c.Args[:len(c.Args):len(c.Args)], defaultHelpArg)
 }
 
-func configureArgs(cmd *Command) {
-	if cmd == nil {
-		return
+func newCmdIndex(cmd *Command) *cmdIndex {
+	return &cmdIndex{cmd: cmd}
+}
+
+func newArgIndex(args []*Arg) *cmdIndex {
+	return &cmdIndex{args: args}
+}
+
+func (idx *cmdIndex) argList() []*Arg {
+	if idx.args == nil && idx.cmd != nil {
+		idx.args = append(append([]*Arg{}, idx.cmd.args()...), idx.cmd.persistentArgs()...)
 	}
 
-	help := false
+	return idx.args
+}
+
+func (idx *cmdIndex) longArg(name string) (*Arg, bool) {
+	idx.countArgFind()
+
+	if idx.bylong != nil {
+		a, ok := idx.bylong[name]
+
+		return a, ok
+	}
+
+	return idx.scanArgs(func(a *Arg) bool {
+		return a.Name == name && name != ""
+	})
+}
+
+func (idx *cmdIndex) shortArg(name rune) (*Arg, bool) {
+	idx.countArgFind()
+
+	if idx.bylong != nil {
+		a, ok := idx.byshort[name]
+
+		return a, ok
+	}
+
+	return idx.scanArgs(func(a *Arg) bool {
+		r, size := utf8.DecodeRuneInString(a.Name)
+
+		return (a.Name != "" && size == len(a.Name) && r == name) || a.ShortName == name
+	})
+}
+
+func (idx *cmdIndex) scanArgs(match func(a *Arg) bool) (*Arg, bool) {
+	if idx.cmd == nil {
+		return findArg(idx.args, match)
+	}
+
+	if a, ok := findArg(idx.cmd.Args, match); ok {
+		return a, true
+	}
+
+	if !idx.cmd.hasHelpArg() && match(defaultHelpArg) {
+		return defaultHelpArg, true
+	}
+
+	for parent := idx.cmd.parent; parent != nil; parent = parent.parent {
+		for _, a := range parent.Args {
+			if a.Persistent && match(a) {
+				return a, true
+			}
+		}
+	}
+
+	return nil, false
+}
 
-	for _, a := range cmd.Args {
+func findArg(args []*Arg, match func(a *Arg) bool) (*Arg, bool) {
+	for _, a := range args {
+		if match(a) {
+			return a, true
+		}
+	}
+
+	return nil, false
+}
+
+func (idx *cmdIndex) countArgFind() {
+	if idx.argfinds++; idx.bylong == nil && idx.argfinds > indexAfter {
+		idx.indexArgs()
+	}
+}
+
+func (idx *cmdIndex) indexArgs() {
+	idx.bylong = map[string]*Arg{}
+	idx.byshort = map[rune]*Arg{}
+
+	addShort := func(r rune, a *Arg) {
+		if _, ok := idx.byshort[r]; !ok {
+			idx.byshort[r] = a
+		}
+	}
+
+	for _, a := range idx.argList() {
+		if _, ok := idx.bylong[a.Name]; !ok && a.Name != "" {
+			idx.bylong[a.Name] = a
+		}
+
+		if utf8.RuneCountInString(a.Name) == 1 {
+			r, _ := utf8.DecodeRuneInString(a.Name)
+			addShort(r, a)
+		}
+
+		if a.ShortName != 0 {
+			addShort(a.ShortName, a)
+		}
+	}
+}
+
+func (idx *cmdIndex) sub(name string) (*subcommand, bool) {
+	idx.countSubFind()
+
+	if idx.byname != nil {
+		sub, ok := idx.byname[name]
+
+		return sub, ok
+	}
+
+	for _, sub := range idx.cmd.subcmds {
+		if sub.hasName(name) {
+			return sub, true
+		}
+	}
+
+	return nil, false
+}
+
+func (idx *cmdIndex) countSubFind() {
+	if idx.subfinds++; idx.byname == nil && idx.subfinds > indexAfter {
+		idx.indexSubs()
+	}
+}
+
+func (idx *cmdIndex) indexSubs() {
+	idx.byname = map[string]*subcommand{}
+
+	for _, sub := range idx.cmd.subcmds {
+		for _, name := range sub.names() {
+			if _, ok := idx.byname[name]; !ok {
+				idx.byname[name] = sub
+			}
+		}
+	}
+}
+
+func (idx *cmdIndex) matchPrefix(prefix string) []*subcommand {
+	var matches []*subcommand
+
+	for _, sub := range idx.cmd.subcmds {
+		for _, name := range sub.names() {
+			if strings.HasPrefix(name, prefix) {
+				matches = append(matches, sub)
+
+				break
+			}
+		}
+	}
+
+	return matches
+}
+
+func (s *subcommand) hasName(name string) bool {
+	if s.fn != nil {
+		return s.name == name
+	}
+
+	return s.cmd.Name == name || containsString(s.cmd.Aliases, name)
+}
+
+func (s *subcommand) names() []string {
+	if s.fn != nil {
+		return []string{s.name}
+	}
+
+	return append([]string{s.cmd.Name}, s.cmd.Aliases...)
+}
+
+func (s *subcommand) resolve(parent *Command) *Command {
+	s.once.Do(func() {
+		if s.fn == nil {
+			return
+		}
+
+		if s.cmd = s.fn(); s.cmd != nil {
+			if s.cmd.Name == "" {
+				s.cmd.Name = s.name
+			}
+
+			s.cmd.parent = parent
+		}
+	})
+
+	return s.cmd
+}
+
+func (c *Command) persistentArgs() []*Arg {
+	var args []*Arg
+
+	for parent := c.parent; parent != nil; parent = parent.parent {
+		for _, a := range parent.Args {
+			if a.Persistent {
+				args = append(args, a)
+			}
+		}
+	}
+
+	return args
+}
+
+func (c *Command) hasHelpArg() bool {
+	for _, a := range c.Args {
 		if a.IsHelp || (a.Name == "help" || a.Name == "h" || a.ShortName == 'h') {
-			help = true
+			return true
 		}
 	}
 
-	if !help {
-		val := true
-		cmd.Args = append(cmd.Args, &Arg{
-			Binder:     NewBoolArgBinder(&val),
-			IsHelp:     true,
-			Name:       "help",
-			Repeatable: true,
-			ShortName:  'h',
-			Usage:      "display usage information for this Command",
-		})
-		cmd.helper = newHelper(cmd, nil)
+	return false
+}
+
+func (c *Command) help(syn ArgSyntax, h *helper) string {
+	if c.helper != nil {
+		return c.helper.Help(syn)
+	}
+
+	if !c.hasHelpArg() {
+		h.cmd = c
+
+		return h.Help(syn)
 	}
+
+	return ""
+}
+
+func cmdPath(c *Command, root string) string {
+	var names []string
+
+	for cmd := c; cmd != nil; cmd = cmd.parent {
+		name := cmd.Name
+
+		if cmd.parent == nil {
+			name = root
+		}
+
+		if name != "" {
+			names = append([]string{name}, names...)
+		}
+	}
+
+	return strings.Join(names, " ")
+}
+
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+
+	return false
 }
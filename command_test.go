@@ -0,0 +1,149 @@
+// Copyright 2020 Stephen Buckler. All rights reserved.
+// Use of this source code is governed by a MIT license
+// that can be found in the LICENSE file.
+
+package clapr_test
+
+import (
+	"context"
+	"fmt"
+	"github.com/sebuckler/clapr"
+	"reflect"
+	"testing"
+)
+
+type testcmdfn func(t *testing.T, name string, root *clapr.Command)
+
+func TestCommand(t *testing.T) {
+	testCases := map[string]testcmdfn{
+		"should return subcommands":   shouldReturnSubcmds,
+		"should return parent":        shouldReturnParent,
+		"should return path":          shouldReturnPath,
+		"should find by name":         shouldFindByName,
+		"should not find unknown":     shouldNotFindUnknown,
+		"should walk tree in order":   shouldWalkTree,
+		"should stop walk when error": shouldStopWalk,
+		"should run after change":     shouldRunAfterChange,
+		"should run copy":             shouldRunCopy,
+	}
+
+	for name, test := range testCases {
+		test(t, name, getTestTree())
+	}
+}
+
+func shouldReturnSubcmds(t *testing.T, name string, root *clapr.Command) {
+	subcmds := root.Subcommands()
+	subcmds[0] = nil
+
+	if len(subcmds) != 2 || root.Subcommands()[0] == nil {
+		t.Fail()
+		t.Logf("%s: unexpected subcommands: %v", name, root.Subcommands())
+	}
+}
+
+func shouldReturnParent(t *testing.T, name string, root *clapr.Command) {
+	if root.Parent() != nil || root.Subcommands()[0].Parent() != root {
+		t.Fail()
+		t.Logf("%s: unexpected parent", name)
+	}
+}
+
+func shouldReturnPath(t *testing.T, name string, root *clapr.Command) {
+	if path := root.Find("db", "migrate").Path(); path != "tool db migrate" {
+		t.Fail()
+		t.Logf("%s: unexpected path: %s", name, path)
+	}
+}
+
+func shouldFindByName(t *testing.T, name string, root *clapr.Command) {
+	if cmd := root.Find("db", "mg"); cmd == nil || cmd.Name != "migrate" {
+		t.Fail()
+		t.Logf("%s: did not find command", name)
+	}
+
+	if root.Find() != root {
+		t.Fail()
+		t.Logf("%s: did not find root", name)
+	}
+}
+
+func shouldNotFindUnknown(t *testing.T, name string, root *clapr.Command) {
+	if cmd := root.Find("db", "foo"); cmd != nil {
+		t.Fail()
+		t.Logf("%s: found command: %s", name, cmd.Name)
+	}
+}
+
+func shouldWalkTree(t *testing.T, name string, root *clapr.Command) {
+	var paths []string
+	expect := []string{"tool", "tool db", "tool db migrate", "tool status"}
+	err := clapr.Walk(root, func(cmd *clapr.Command) error {
+		paths = append(paths, cmd.Path())
+
+		return nil
+	})
+
+	if err != nil || !reflect.DeepEqual(paths, expect) {
+		t.Fail()
+		t.Logf("%s: expected: %v got: %v", name, expect, paths)
+	}
+}
+
+func shouldStopWalk(t *testing.T, name string, root *clapr.Command) {
+	count := 0
+	err := clapr.Walk(root, func(cmd *clapr.Command) error {
+		count++
+
+		if cmd.Name == "db" {
+			return fmt.Errorf("should error")
+		}
+
+		return nil
+	})
+
+	if err == nil || count != 2 {
+		t.Fail()
+		t.Logf("%s: did not stop walking, visited: %d", name, count)
+	}
+}
+
+func shouldRunAfterChange(t *testing.T, name string, root *clapr.Command) {
+	ran := false
+
+	if err := clapr.NewRunner(root, clapr.GNU, clapr.WithArgs("status")).Run(context.Background()); err != nil {
+		t.Fail()
+		t.Logf("%s: errored: %v", name, err)
+	}
+
+	root.AddSubcommand(&clapr.Command{
+		Name: "lint",
+		Run: func(context.Context, []string) {
+			ran = true
+		},
+	})
+
+	if err := clapr.NewRunner(root, clapr.GNU, clapr.WithArgs("lint")).Run(context.Background()); err != nil || !ran {
+		t.Fail()
+		t.Logf("%s: ran: %t, err: %v", name, ran, err)
+	}
+}
+
+func shouldRunCopy(t *testing.T, name string, root *clapr.Command) {
+	copied := *root
+	copied.Name = "copy"
+
+	if err := clapr.NewRunner(&copied, clapr.GNU, clapr.WithArgs("db", "migrate")).Run(context.Background()); err != nil {
+		t.Fail()
+		t.Logf("%s: errored: %v", name, err)
+	}
+}
+
+func getTestTree() *clapr.Command {
+	root := &clapr.Command{Name: "tool"}
+	db := &clapr.Command{Name: "db"}
+	db.AddSubcommand(&clapr.Command{Aliases: []string{"mg"}, Name: "migrate"})
+	root.AddSubcommand(db, &clapr.Command{Name: "status"})
+
+	return root
+}
@@ -29,7 +29,8 @@ a runner. The runner will parse and execute the commands and arguments
 provided through the command line. During parsing, certain errors can
 be handled and printed out to show help text to the end user. Command
 execution through the runner passes a context object to each command's
-Run function.
+Run function. Main creates and runs a runner for a command, prints help
+and errors for the end user, and exits with the status of the run.
 
 Example code:
 
@@ -71,15 +72,10 @@ Example code:
 		}}
 		cmd.AddSubcommand(subcmd)
 
-		// Create the runner to parse and execute the root command
-		// and its subcommand
-		runner := clapr.NewRunner(cmd, clapr.GNU)
-
-		// Handle any errors during parsing or execution and print
-		// them out so the user can see what went wrong
-		if err := runner.Run(context.Background()); err != nil {
-			fmt.Println(err)
-		}
+		// Parse and execute the root command and its subcommand,
+		// then exit with the status of the run. Help and errors are
+		// printed so the user can see what went wrong
+		clapr.Main(cmd, clapr.GNU)
 	}
 */
 package clapr
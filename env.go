@@ -0,0 +1,86 @@
+// Copyright 2020 Stephen Buckler. All rights reserved.
+// Use of this source code is governed by a MIT license
+// that can be found in the LICENSE file.
+
+package clapr
+
+import (
+	"context"
+	"os"
+	"strings"
+)
+
+type envKey struct{}
+
+/*
+Env returns the environment of the Runner running the command the
+context was passed to, as set by WithEnv. If the context did not come
+from a Runner or the Runner was not given an environment, the process's
+environment is returned.
+*/
+func Env(ctx context.Context) []string {
+	if env, ok := ctx.Value(envKey{}).([]string); ok {
+		return append([]string{}, env...)
+	}
+
+	return os.Environ()
+}
+
+/*
+Getenv returns the value of the environment variable named by key in
+the environment returned by Env, or an empty string if it is not set.
+*/
+func Getenv(ctx context.Context, key string) string {
+	if env, ok := ctx.Value(envKey{}).([]string); ok {
+		return lookupEnv(env, key)
+	}
+
+	return os.Getenv(key)
+}
+
+func (r *runner) environ() []string {
+	if r.env != nil {
+		return r.env
+	}
+
+	return os.Environ()
+}
+
+func (r *runner) getenv(key string) string {
+	if r.env == nil {
+		return os.Getenv(key)
+	}
+
+	return lookupEnv(r.env, key)
+}
+
+func lookupEnv(env []string, key string) string {
+	for i := len(env) - 1; i >= 0; i-- {
+		if strings.HasPrefix(env[i], key+"=") {
+			return strings.TrimPrefix(env[i], key+"=")
+		}
+	}
+
+	return ""
+}
+
+func (r *runner) isMultiCall() bool {
+	if r.progname == "" || r.root == nil || r.progname == r.root.Name {
+		return false
+	}
+
+	_, ok := newCmdIndex(r.root).sub(r.progname)
+
+	return ok
+}
+
+func (r *runner) rootName() string {
+	switch {
+	case r.isMultiCall():
+		return ""
+	case r.progname != "":
+		return r.progname
+	}
+
+	return r.root.Name
+}
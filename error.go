@@ -4,6 +4,12 @@
 
 package clapr
 
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
 /*
 ErrHelp represents an error that occurs during argument parsing. It
 satisfies the Error interface.
@@ -19,6 +25,160 @@ func (e *ErrHelp) Error() string {
 	return e.Help
 }
 
+/*
+ErrVersion is returned instead of running any commands when the
+built-in version option is parsed. It satisfies the Error interface.
+*/
+type ErrVersion struct {
+	Version string // Output text describing the utility version
+}
+
+/*
+Error just returns the version text.
+*/
+func (e *ErrVersion) Error() string {
+	return e.Version
+}
+
+/*
+ExitError carries the process exit status for an error returned by a
+Runner. Parsing errors are returned as an ExitError with ExitUsage. It
+satisfies the Error interface.
+*/
+type ExitError struct {
+	Code int   // Exit status the process should exit with
+	Err  error // Underlying error
+}
+
+const (
+	ExitOK      = 0  // Successful termination, also used for requested help
+	ExitFailure = 1  // General failure of a command
+	ExitUsage   = 64 // Incorrect command line usage, EX_USAGE from sysexits.h
+)
+
+/*
+Error returns the message of the underlying error.
+*/
+func (e *ExitError) Error() string {
+	return e.Err.Error()
+}
+
+/*
+Unwrap returns the underlying error.
+*/
+func (e *ExitError) Unwrap() error {
+	return e.Err
+}
+
+/*
+ExitCode returns the exit status for an error returned by a Runner.
+It is ExitOK for nil and for help or version information requested
+from the command line, the code of an ExitError, the exit status of a
+plugin that failed, and ExitFailure for any other error.
+*/
+func ExitCode(err error) int {
+	var exitErr *ExitError
+	var errhelp *ErrHelp
+	var errversion *ErrVersion
+	var coder interface{ ExitCode() int }
+
+	switch {
+	case err == nil:
+		return ExitOK
+	case errors.As(err, &exitErr):
+		return exitErr.Code
+	case errors.As(err, &errhelp), errors.As(err, &errversion):
+		return ExitOK
+	case errors.As(err, &coder) && coder.ExitCode() > 0:
+		return coder.ExitCode()
+	}
+
+	return ExitFailure
+}
+
+func usageError(err error) error {
+	switch err.(type) {
+	case nil, *ErrHelp, *ErrVersion:
+		return err
+	}
+
+	return &ExitError{Code: ExitUsage, Err: err}
+}
+
+/*
+CommandError identifies the command that failed when commands are run
+with WithChaining. It satisfies the Error interface.
+*/
+type CommandError struct {
+	Command *Command // Command that failed
+	Err     error    // Error returned while running the command
+}
+
+/*
+Error returns the command path followed by the error it failed with.
+*/
+func (e *CommandError) Error() string {
+	if e.Command == nil || e.Command.Path() == "" {
+		return e.Err.Error()
+	}
+
+	return fmt.Sprintf("%s: %v", e.Command.Path(), e.Err)
+}
+
+/*
+Unwrap returns the error the command failed with.
+*/
+func (e *CommandError) Unwrap() error {
+	return e.Err
+}
+
+/*
+ChainError aggregates the errors of every command that failed when
+commands are run with WithChaining. It satisfies the Error interface.
+*/
+type ChainError struct {
+	Errors []*CommandError // Failed commands in the order they were parsed
+}
+
+/*
+Error returns the error of each failed command on its own line.
+*/
+func (e *ChainError) Error() string {
+	var msgs []string
+
+	for _, err := range e.Errors {
+		msgs = append(msgs, err.Error())
+	}
+
+	return strings.Join(msgs, "\n")
+}
+
+/*
+Is reports whether any of the failed commands' errors matches target.
+*/
+func (e *ChainError) Is(target error) bool {
+	for _, err := range e.Errors {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+
+	return false
+}
+
+/*
+As finds the first of the failed commands' errors that matches target.
+*/
+func (e *ChainError) As(target interface{}) bool {
+	for _, err := range e.Errors {
+		if errors.As(err, target) {
+			return true
+		}
+	}
+
+	return false
+}
+
 type errTerm struct {
 	index int
 }
@@ -0,0 +1,251 @@
+// Copyright 2020 Stephen Buckler. All rights reserved.
+// Use of this source code is governed by a MIT license
+// that can be found in the LICENSE file.
+
+package clapr
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+type execNode struct {
+	children []*execNode
+	cmd      *parsedCmd
+	parallel bool
+}
+
+func newExecTree(parsed []*parsedCmd) *execNode {
+	root := &execNode{cmd: parsed[0]}
+	stack := []*execNode{root}
+
+	for _, cmd := range parsed[1:] {
+		for len(stack) > 1 && !isAncestor(stack[len(stack)-1].cmd.cmddef, cmd.cmddef) {
+			stack = stack[:len(stack)-1]
+		}
+
+		node := &execNode{cmd: cmd}
+		parent := stack[len(stack)-1]
+		parent.children = append(parent.children, node)
+		stack = append(stack, node)
+	}
+
+	return root
+}
+
+func isAncestor(ancestor *Command, cmd *Command) bool {
+	for parent := cmd.parent; parent != nil; parent = parent.parent {
+		if parent == ancestor {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (p *parser) exec(ctx context.Context, node *execNode) (err error) {
+	cmd := node.cmd.cmddef
+	operands := node.cmd.operands
+
+	if cmd.PersistentPreRun != nil {
+		if err := ctx.Err(); err != nil && !node.parallel {
+			return p.cmdError(cmd, err)
+		}
+
+		if err := cmd.PersistentPreRun(ctx, operands); err != nil {
+			return p.cmdError(cmd, err)
+		}
+	}
+
+	if cmd.PersistentPostRun != nil {
+		defer func() {
+			if posterr := cmd.PersistentPostRun(ctx, operands); err == nil {
+				err = p.cmdError(cmd, posterr)
+			}
+		}()
+	}
+
+	if p.shouldExec(node) {
+		if err := p.execCmd(ctx, node); err != nil {
+			return p.cmdError(cmd, err)
+		}
+	}
+
+	return p.execChildren(ctx, node.children)
+}
+
+func (p *parser) execChildren(ctx context.Context, children []*execNode) error {
+	if p.chain != ChainParallel || len(children) < 2 {
+		for _, child := range children {
+			if err := p.exec(ctx, child); err != nil {
+				return p.chainError(err)
+			}
+		}
+
+		return nil
+	}
+
+	groupctx, cancel := context.WithCancel(ctx)
+	errs := make([]error, len(children))
+	var wg sync.WaitGroup
+
+	defer cancel()
+
+	for i, child := range children {
+		child.parallel = true
+		wg.Add(1)
+
+		go func(i int, child *execNode) {
+			defer wg.Done()
+
+			if errs[i] = p.exec(groupctx, child); errs[i] != nil {
+				cancel()
+			}
+		}(i, child)
+	}
+
+	wg.Wait()
+
+	return p.chainError(errs...)
+}
+
+func (p *parser) cmdError(cmd *Command, err error) error {
+	if p.chain == ChainDefault || err == nil {
+		return err
+	}
+
+	return &CommandError{Command: cmd, Err: err}
+}
+
+func (p *parser) chainError(errs ...error) error {
+	var failed []*CommandError
+	var canceled []*CommandError
+
+	if p.chain == ChainDefault {
+		return errs[0]
+	}
+
+	for _, err := range errs {
+		var cmderrs []*CommandError
+
+		switch e := err.(type) {
+		case nil:
+			continue
+		case *ChainError:
+			cmderrs = e.Errors
+		case *CommandError:
+			cmderrs = []*CommandError{e}
+		default:
+			cmderrs = []*CommandError{{Err: e}}
+		}
+
+		for _, cmderr := range cmderrs {
+			if errors.Is(cmderr.Err, context.Canceled) {
+				canceled = append(canceled, cmderr)
+			} else {
+				failed = append(failed, cmderr)
+			}
+		}
+	}
+
+	if len(failed) == 0 {
+		failed = canceled
+	}
+
+	if len(failed) == 0 {
+		return nil
+	}
+
+	return &ChainError{Errors: failed}
+}
+
+func (p *parser) shouldExec(node *execNode) bool {
+	switch p.policy {
+	case RunLeaf:
+		return len(node.children) == 0
+	case RunExplicit:
+		return !node.cmd.cmddef.Passthrough || len(node.children) == 0
+	}
+
+	return true
+}
+
+func (p *parser) execCmd(ctx context.Context, node *execNode) (err error) {
+	parsed := node.cmd
+	cmd := parsed.cmddef
+
+	if !hasRunFunc(cmd) {
+		return nil
+	}
+
+	if err := ctx.Err(); err != nil && !node.parallel {
+		return err
+	}
+
+	if cmd.PreRun != nil {
+		if err := cmd.PreRun(ctx, parsed.operands); err != nil {
+			return err
+		}
+	}
+
+	if cmd.PostRun != nil {
+		defer func() {
+			if posterr := cmd.PostRun(ctx, parsed.operands); err == nil {
+				err = posterr
+			}
+		}()
+	}
+
+	if run := getRunFunc(cmd); run != nil {
+		return p.runWithTimeout(ctx, cmd, wrapRunFunc(run, p.middleware, cmd.Middleware), parsed.operands)
+	}
+
+	return nil
+}
+
+func (p *parser) runWithTimeout(ctx context.Context, cmd *Command, run RunFunc, operands []string) error {
+	timeout := cmd.Timeout
+
+	if p.timeout > 0 {
+		timeout = p.timeout
+	}
+
+	if timeout <= 0 {
+		return run(ctx, operands)
+	}
+
+	runctx, cancel := context.WithTimeout(ctx, timeout)
+
+	defer cancel()
+
+	err := run(runctx, operands)
+
+	if ctx.Err() == nil && errors.Is(runctx.Err(), context.DeadlineExceeded) {
+		return timeoutError(cmd, timeout)
+	}
+
+	return err
+}
+
+func timeoutError(cmd *Command, timeout time.Duration) error {
+	return fmt.Errorf("command %s timed out after %v: %w", cmd.Path(), timeout, context.DeadlineExceeded)
+}
+
+func getRunFunc(cmd *Command) RunFunc {
+	if cmd.RunE != nil {
+		return cmd.RunE
+	}
+
+	if cmd.Run != nil {
+		return func(ctx context.Context, operands []string) error {
+			cmd.Run(ctx, operands)
+
+			return nil
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,92 @@
+// Copyright 2020 Stephen Buckler. All rights reserved.
+// Use of this source code is governed by a MIT license
+// that can be found in the LICENSE file.
+
+package clapr
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+func (p *parser) shouldExplain() bool {
+	if p.explain {
+		return true
+	}
+
+	for _, cmd := range p.parsed {
+		for _, arg := range cmd.parsedargs {
+			if arg.argdef == p.explainarg {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+func (p *parser) explainPlugin(w io.Writer, path string) error {
+	_, err := fmt.Fprintf(w, "1. plugin %s %s\n", path, quoteAll(p.argv[1:]))
+
+	return err
+}
+
+func (p *parser) explainTree(w io.Writer, root *execNode) error {
+	var lines []string
+	var visit func(node *execNode, parallel bool)
+
+	visit = func(node *execNode, parallel bool) {
+		cmd := node.cmd
+		ln := fmt.Sprintf("%d. %s", len(lines)+1, cmdPath(cmd.cmddef, p.rootName()))
+
+		if !p.shouldExec(node) || !hasRunFunc(cmd.cmddef) {
+			ln += " (not run)"
+		} else if parallel {
+			ln += " (parallel)"
+		}
+
+		for _, arg := range cmd.parsedargs {
+			if arg.argdef == p.explainarg {
+				continue
+			}
+
+			ln += fmt.Sprintf("\n    %s", strings.SplitN(arg.raw, "=", 2)[0])
+
+			if arg.val != "" {
+				ln += fmt.Sprintf(" = %q", arg.val)
+			}
+
+			ln += fmt.Sprintf(" (%s)", arg.source)
+		}
+
+		if len(cmd.operands) > 0 {
+			ln += fmt.Sprintf("\n    operands: %s", quoteAll(cmd.operands))
+		}
+
+		lines = append(lines, ln)
+
+		for _, child := range node.children {
+			visit(child, p.chain == ChainParallel && len(node.children) > 1)
+		}
+	}
+
+	visit(root, false)
+	_, err := fmt.Fprintln(w, strings.Join(lines, "\n"))
+
+	return err
+}
+
+func hasRunFunc(cmd *Command) bool {
+	return cmd.Run != nil || cmd.RunE != nil || cmd.PreRun != nil || cmd.PostRun != nil
+}
+
+func quoteAll(list []string) string {
+	var quoted []string
+
+	for _, s := range list {
+		quoted = append(quoted, fmt.Sprintf("%q", s))
+	}
+
+	return strings.Join(quoted, ", ")
+}
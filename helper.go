@@ -8,6 +8,7 @@ import (
 	"fmt"
 	"math"
 	"strings"
+	"unicode/utf8"
 )
 
 /*
@@ -20,12 +21,15 @@ type Helper interface {
 }
 
 type helper struct {
-	cmd *Command
-	fn  func(cmd *Command, syn ArgSyntax) string
+	builtins []*Arg
+	cmd      *Command
+	fn       func(cmd *Command, syn ArgSyntax) string
+	plugins  []string
+	root     string
 }
 
 func newHelper(cmd *Command, fn func(cmd *Command, syn ArgSyntax) string) Helper {
-	return &helper{cmd, fn}
+	return &helper{cmd: cmd, fn: fn}
 }
 
 func (h *helper) Help(syn ArgSyntax) string {
@@ -38,17 +42,9 @@ func (h *helper) Help(syn ArgSyntax) string {
 
 func (h *helper) getHelpTemplate(syn ArgSyntax) string {
 	var w strings.Builder
-	parent := h.cmd.parent
-	var parents []string
-	var parentuse string
-	longestln := float64(0)
-	var lines [][]string
 	var opts []*Arg
-
-	for parent != nil {
-		parents = append(parents, h.cmd.parent.Name)
-		parent = parent.parent
-	}
+	global := h.cmd.persistentArgs()
+	subcmds := h.cmd.subcmds
 
 	for _, a := range h.cmd.Args {
 		if a.IsHelp {
@@ -58,69 +54,115 @@ func (h *helper) getHelpTemplate(syn ArgSyntax) string {
 		opts = append(opts, a)
 	}
 
-	if len(parents) > 0 {
-		parentuse = fmt.Sprintf("%s ", strings.Join(parents, " "))
+	if h.cmd.parent == nil {
+		opts = append(opts, h.builtins...)
+	} else {
+		global = append(global, h.builtins...)
 	}
 
-	w.WriteString(fmt.Sprintf(`Usage:
-    %s%s`, parentuse, h.cmd.Name))
+	opts, global = visibleArgs(opts), visibleArgs(global)
 
-	if len(h.cmd.subcmds) > 0 {
-		w.WriteString(` [command] <options>
+	w.WriteString(fmt.Sprintf(`Usage:
+    %s`, cmdPath(h.cmd, h.root)))
 
-Commands:
-`)
-	} else if len(opts) > 0 {
+	if len(subcmds) > 0 || len(h.plugins) > 0 {
+		w.WriteString(` [command] <options>`)
+	} else if len(opts) > 0 || len(global) > 0 {
 		w.WriteString(` <options>`)
 	}
 
-	for _, cmd := range h.cmd.subcmds {
-		w.WriteString(fmt.Sprintf("%s[%s]  %s", strings.Repeat(" ", 4), cmd.Name, cmd.Usage))
+	if len(subcmds) > 0 {
+		w.WriteString("\n\nCommands:\n")
 	}
 
-	if len(opts) > 0 {
-		w.WriteString(`
+	for i, sub := range subcmds {
+		usage := sub.usage
+
+		if i > 0 {
+			w.WriteString("\n")
+		}
+
+		if sub.fn == nil {
+			usage = sub.cmd.Usage
+		}
+
+		names := strings.Join(sub.names(), ", ")
+		w.WriteString(fmt.Sprintf("%s[%s]  %s", strings.Repeat(" ", 4), names, usage))
+	}
+
+	if len(h.plugins) > 0 {
+		w.WriteString("\n\nPlugins:\n")
+	}
+
+	for i, name := range h.plugins {
+		if i > 0 {
+			w.WriteString("\n")
+		}
+
+		w.WriteString(fmt.Sprintf("%s[%s]", strings.Repeat(" ", 4), name))
+	}
+
+	writeOptions(&w, "Options", opts, syn)
+	writeOptions(&w, "Global Options", global, syn)
+
+	return w.String()
+}
+
+func visibleArgs(args []*Arg) []*Arg {
+	var visible []*Arg
 
-Options:
-    `)
+	for _, a := range args {
+		if !a.Hidden {
+			visible = append(visible, a)
+		}
 	}
 
+	return visible
+}
+
+func writeOptions(w *strings.Builder, title string, opts []*Arg, syn ArgSyntax) {
+	longestln := float64(0)
+	var lines [][]string
+
 	for _, o := range opts {
 		ln := ""
 
 		if o.ShortName > 0 {
 			ln = fmt.Sprintf("-%s, ", string(o.ShortName))
+		} else if utf8.RuneCountInString(o.Name) == 1 {
+			ln = fmt.Sprintf("-%s, ", o.Name)
 		}
 
-		switch syn {
-		case GNU:
-			if o.Name != "" {
-				if ln == "" {
-					ln = fmt.Sprintf("-%s, ", string(o.Name[0]))
-				}
-
-				ln = fmt.Sprintf("%s--%s", ln, o.Name)
-			}
-		case POSIX:
-			if ln == "" && o.Name != "" {
-				ln = fmt.Sprintf("-%s", string(o.Name[0]))
-			}
+		if syn == GNU && o.Name != "" {
+			ln = fmt.Sprintf("%s--%s", ln, o.Name)
 		}
 
 		ln = strings.TrimSuffix(ln, ", ")
+
+		if ln == "" {
+			continue
+		}
+
 		longestln = math.Max(float64(len(ln)), longestln)
 		lines = append(lines, []string{ln, o.Usage})
 	}
 
-	for i, ln := range lines {
+	if len(lines) == 0 {
+		return
+	}
+
+	if strings.HasSuffix(w.String(), "\n") {
+		w.WriteString("\n")
+	} else {
+		w.WriteString("\n\n")
+	}
+
+	w.WriteString(fmt.Sprintf("%s:\n", title))
+
+	for _, ln := range lines {
+		w.WriteString(strings.Repeat(" ", 4))
 		w.WriteString(ln[0])
 		w.WriteString(strings.Repeat(" ", int(longestln)-len(ln[0])+4))
 		w.WriteString(fmt.Sprintln(ln[1]))
-
-		if i < len(lines)-1 {
-			w.WriteString(strings.Repeat(" ", 4))
-		}
 	}
-
-	return w.String()
 }
@@ -0,0 +1,45 @@
+// Copyright 2020 Stephen Buckler. All rights reserved.
+// Use of this source code is governed by a MIT license
+// that can be found in the LICENSE file.
+
+package clapr
+
+import (
+	"context"
+	"io"
+	"os"
+)
+
+/*
+IOStreams is the set of standard streams a Runner uses for help output,
+plugins, and its commands. Commands get the streams of the Runner that
+is running them with IO.
+*/
+type IOStreams struct {
+	Err io.Writer // Standard error, os.Stderr if nil
+	In  io.Reader // Standard input, os.Stdin if nil
+	Out io.Writer // Standard output, os.Stdout if nil
+}
+
+type ioKey struct{}
+
+/*
+IO returns the streams of the Runner running the command the context
+was passed to. If the context did not come from a Runner, the process's
+standard streams are returned.
+*/
+func IO(ctx context.Context) *IOStreams {
+	if streams, ok := ctx.Value(ioKey{}).(*IOStreams); ok {
+		return streams
+	}
+
+	return newIOStreams()
+}
+
+func newIOStreams() *IOStreams {
+	return &IOStreams{
+		Err: os.Stderr,
+		In:  os.Stdin,
+		Out: os.Stdout,
+	}
+}
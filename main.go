@@ -0,0 +1,36 @@
+// Copyright 2020 Stephen Buckler. All rights reserved.
+// Use of this source code is governed by a MIT license
+// that can be found in the LICENSE file.
+
+package clapr
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+)
+
+/*
+Main runs the command tree with a new Runner and exits the process. Help
+or version information requested from the command line is printed to
+the Runner's output stream, and any other error is printed to its error
+stream. The process exits with the status given by ExitCode.
+*/
+func Main(root *Command, syn ArgSyntax, opts ...RunnerOption) {
+	r := newRunner(root, syn, opts...)
+	err := r.Run(context.Background())
+	code := ExitCode(err)
+	var errhelp *ErrHelp
+	var errversion *ErrVersion
+
+	if err != nil && code == ExitOK && errors.As(err, &errhelp) {
+		fmt.Fprintln(r.streams.Out, errhelp.Help)
+	} else if err != nil && code == ExitOK && errors.As(err, &errversion) {
+		fmt.Fprintln(r.streams.Out, errversion.Version)
+	} else if err != nil {
+		fmt.Fprintln(r.streams.Err, err)
+	}
+
+	os.Exit(code)
+}
@@ -0,0 +1,89 @@
+// Copyright 2020 Stephen Buckler. All rights reserved.
+// Use of this source code is governed by a MIT license
+// that can be found in the LICENSE file.
+
+package clapr_test
+
+import (
+	"bytes"
+	"errors"
+	"github.com/sebuckler/clapr"
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+type testmainfn func(t *testing.T, name string)
+
+func TestMain_Exit(t *testing.T) {
+	if args := os.Getenv("CLAPR_TEST_MAIN_ARGS"); args != "" {
+		os.Args = append([]string{"test"}, strings.Fields(args)...)
+		clapr.Main(&clapr.Command{Name: "test"}, clapr.GNU)
+	}
+
+	testCases := map[string]testmainfn{
+		"should exit zero when run":        shouldExitZeroWhenRun,
+		"should print help to stdout":      shouldPrintHelpToStdout,
+		"should print usage err to stderr": shouldPrintUsageToStderr,
+	}
+
+	for name, test := range testCases {
+		test(t, name)
+	}
+}
+
+func shouldExitZeroWhenRun(t *testing.T, name string) {
+	code, stdout, stderr := runMain(t, "--")
+
+	if code != 0 || stdout != "" || stderr != "" {
+		t.Fail()
+		t.Logf("%s: code: %d, stdout: %q, stderr: %q", name, code, stdout, stderr)
+	}
+}
+
+func shouldPrintHelpToStdout(t *testing.T, name string) {
+	code, stdout, stderr := runMain(t, "--help")
+
+	if code != 0 || !strings.HasPrefix(stdout, "Usage:") || stderr != "" {
+		t.Fail()
+		t.Logf("%s: code: %d, stdout: %q, stderr: %q", name, code, stdout, stderr)
+	}
+}
+
+func shouldPrintUsageToStderr(t *testing.T, name string) {
+	code, stdout, stderr := runMain(t, "--bogus")
+
+	if code != clapr.ExitUsage || stdout != "" || !strings.Contains(stderr, "unknown argument provided: --bogus") {
+		t.Fail()
+		t.Logf("%s: code: %d, stdout: %q, stderr: %q", name, code, stdout, stderr)
+	}
+}
+
+func runMain(t *testing.T, args string) (int, string, string) {
+	bin, err := os.Executable()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var stdout, stderr bytes.Buffer
+	proc := exec.Command(bin, "-test.run=TestMain_Exit")
+	proc.Env = append(os.Environ(), "CLAPR_TEST_MAIN_ARGS="+args)
+	proc.Stdout = &stdout
+	proc.Stderr = &stderr
+	err = proc.Run()
+	var exitErr *exec.ExitError
+
+	if err != nil && !errors.As(err, &exitErr) {
+		t.Fatal(err)
+	}
+
+	code := 0
+
+	if exitErr != nil {
+		code = exitErr.ExitCode()
+	}
+
+	return code, stdout.String(), stderr.String()
+}
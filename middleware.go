@@ -0,0 +1,32 @@
+// Copyright 2020 Stephen Buckler. All rights reserved.
+// Use of this source code is governed by a MIT license
+// that can be found in the LICENSE file.
+
+package clapr
+
+import (
+	"context"
+)
+
+/*
+RunFunc is a command's run function as seen by middleware. Commands
+using Run instead of RunE are adapted to always return a nil error.
+*/
+type RunFunc func(ctx context.Context, operands []string) error
+
+/*
+Middleware wraps a RunFunc with cross-cutting behavior, such as timing,
+panic recovery, or audit logging. It must call next to continue
+execution of the command.
+*/
+type Middleware func(next RunFunc) RunFunc
+
+func wrapRunFunc(fn RunFunc, mw ...[]Middleware) RunFunc {
+	for i := len(mw) - 1; i >= 0; i-- {
+		for j := len(mw[i]) - 1; j >= 0; j-- {
+			fn = mw[i][j](fn)
+		}
+	}
+
+	return fn
+}
@@ -0,0 +1,70 @@
+// Copyright 2020 Stephen Buckler. All rights reserved.
+// Use of this source code is governed by a MIT license
+// that can be found in the LICENSE file.
+
+package clapr
+
+import (
+	"fmt"
+)
+
+/*
+OperandValidator checks the operands parsed for a command. It is called
+after parsing and before any command is executed. A returned error is
+reported along with the command's help text.
+*/
+type OperandValidator func(operands []string) error
+
+/*
+NoOperands is an OperandValidator that errors if any operands are
+provided.
+*/
+func NoOperands(operands []string) error {
+	if len(operands) > 0 {
+		return fmt.Errorf("no operands accepted, received %d", len(operands))
+	}
+
+	return nil
+}
+
+/*
+ExactOperands returns an OperandValidator that errors unless exactly n
+operands are provided.
+*/
+func ExactOperands(n int) OperandValidator {
+	return func(operands []string) error {
+		if len(operands) != n {
+			return fmt.Errorf("expected %d operand(s), received %d", n, len(operands))
+		}
+
+		return nil
+	}
+}
+
+/*
+MinOperands returns an OperandValidator that errors if fewer than n
+operands are provided.
+*/
+func MinOperands(n int) OperandValidator {
+	return func(operands []string) error {
+		if len(operands) < n {
+			return fmt.Errorf("expected at least %d operand(s), received %d", n, len(operands))
+		}
+
+		return nil
+	}
+}
+
+/*
+RangeOperands returns an OperandValidator that errors unless the number
+of operands provided is between min and max, inclusive.
+*/
+func RangeOperands(min int, max int) OperandValidator {
+	return func(operands []string) error {
+		if len(operands) < min || len(operands) > max {
+			return fmt.Errorf("expected %d to %d operands, received %d", min, max, len(operands))
+		}
+
+		return nil
+	}
+}
@@ -0,0 +1,281 @@
+// Copyright 2020 Stephen Buckler. All rights reserved.
+// Use of this source code is governed by a MIT license
+// that can be found in the LICENSE file.
+
+package clapr
+
+import "io"
+
+/*
+RunnerOption configures optional behavior of a Runner. Options are
+passed to NewRunner.
+*/
+type RunnerOption func(r *runner)
+
+/*
+WithPrefixMatching allows subcommands to be called by any unique prefix
+of their name or aliases. Exact names always take precedence. Only the
+first non-option argument after a command is matched by prefix, and only
+against that command's own subcommands, so operands, option values and
+arguments after "--" are never treated as commands. A prefix matching
+more than one subcommand is a parsing error that lists the candidates.
+*/
+func WithPrefixMatching() RunnerOption {
+	return func(r *runner) {
+		r.prefix = true
+	}
+}
+
+/*
+WithMiddleware wraps the run function of every executed command. Runner
+middleware runs outside of any Middleware set on the Command itself,
+and the first middleware passed is the outermost.
+*/
+func WithMiddleware(mw ...Middleware) RunnerOption {
+	return func(r *runner) {
+		r.middleware = append(r.middleware, mw...)
+	}
+}
+
+/*
+WithRunPolicy selects which parsed commands are executed. By default,
+RunChain is used and every parsed command runs from the root down.
+*/
+func WithRunPolicy(policy RunPolicy) RunnerOption {
+	return func(r *runner) {
+		r.policy = policy
+	}
+}
+
+/*
+WithPlugins dispatches unknown subcommands of the root command to
+external executables named "<root>-<subcommand>", in the style of git.
+Executables are looked up in the directories given, made absolute
+against the working directory, or in the PATH environment variable if
+none are given. Relative PATH entries, including empty ones, are
+skipped. The remaining arguments,
+environment, and standard streams are passed through to the plugin,
+and the root command's help text lists any plugins found. A plugin is
+only run when its name is the first argument, so "tool myplugin
+--verbose" runs it but "tool --verbose myplugin" does not. Plugins are
+disabled unless the root command has a Name or WithProgramName is used.
+*/
+func WithPlugins(dirs ...string) RunnerOption {
+	return func(r *runner) {
+		r.plugins = true
+		r.plugindirs = append(r.plugindirs, dirs...)
+	}
+}
+
+/*
+WithAliases expands user-defined aliases before the command line is
+parsed. When the first argument is the name of an alias rather than a
+subcommand, it is replaced by the alias expansion, which is split into
+arguments using shell-style quoting. An alias may expand to another
+alias, but recursive expansion is an error. Aliases can be loaded from
+a file with LoadAliases.
+*/
+func WithAliases(aliases map[string]string) RunnerOption {
+	return func(r *runner) {
+		if r.aliases == nil {
+			r.aliases = map[string]string{}
+		}
+
+		for name, expansion := range aliases {
+			r.aliases[name] = expansion
+		}
+	}
+}
+
+/*
+WithChaining runs sibling subcommands parsed from the same command line
+as a chain, each with its own options. With ChainSequential, siblings
+run in order and the chain stops at the first failure. With
+ChainParallel, siblings run concurrently and share a context that is
+canceled when any of them fails. Every sibling is started even if the
+context is already canceled, so each must watch ctx.Done() to stop
+early. Either way, Run returns a ChainError identifying each command
+that failed.
+*/
+func WithChaining(mode ChainMode) RunnerOption {
+	return func(r *runner) {
+		r.chain = mode
+	}
+}
+
+/*
+WithTimeoutFlag adds a built-in "--timeout" option accepted after any
+command when parsing GNU syntax, such as "--timeout=30s". When given,
+it overrides the Timeout of every executed command. An option of the
+same name defined on a Command takes precedence over the built-in one.
+The flag is not added when parsing POSIX syntax, where commands only
+use their own Timeout.
+*/
+func WithTimeoutFlag() RunnerOption {
+	return func(r *runner) {
+		r.timeoutflag = true
+	}
+}
+
+/*
+WithSignalHandling derives the context passed to Run's commands from
+SIGINT and SIGTERM. The first signal cancels the context and runs the
+cleanup functions given, in order, and Run waits for them to finish
+before returning. A second signal exits the process immediately with
+the conventional status of 128 plus the signal number.
+*/
+func WithSignalHandling(cleanup ...func()) RunnerOption {
+	return func(r *runner) {
+		r.signals = true
+		r.cleanup = append(r.cleanup, cleanup...)
+	}
+}
+
+/*
+WithIO replaces the standard streams used by the Runner for help
+output and plugins, and returned to its commands by IO. Streams left
+nil keep using the process's standard streams.
+*/
+func WithIO(streams IOStreams) RunnerOption {
+	return func(r *runner) {
+		if streams.Err != nil {
+			r.streams.Err = streams.Err
+		}
+
+		if streams.In != nil {
+			r.streams.In = streams.In
+		}
+
+		if streams.Out != nil {
+			r.streams.Out = streams.Out
+		}
+	}
+}
+
+/*
+WithArgs sets the command line arguments to parse, not including the
+program name, instead of os.Args[1:]. It lets a tree be run against any
+list of arguments without changing os.Args.
+*/
+func WithArgs(args ...string) RunnerOption {
+	return func(r *runner) {
+		r.argv = args
+	}
+}
+
+/*
+WithEnv sets the environment used by the Runner instead of the
+process's environment. Each entry has the form "key=value", as returned
+by os.Environ. It is used to look up plugins on PATH, is the
+environment plugins are run with, and is what commands get from Env
+and Getenv.
+*/
+func WithEnv(env []string) RunnerOption {
+	return func(r *runner) {
+		r.env = append([]string{}, env...)
+	}
+}
+
+/*
+WithProgramName sets the name the program was called by, such as the
+base name of os.Args[0]. It replaces the root command's name in help
+text output and in the prefix of plugin executables. If it matches a
+subcommand of the root command instead, that subcommand is run as if
+it was the first argument, so one binary can be installed under the
+names of several commands.
+*/
+func WithProgramName(name string) RunnerOption {
+	return func(r *runner) {
+		r.progname = name
+	}
+}
+
+/*
+WithSyntax overrides the ArgSyntax passed to NewRunner.
+*/
+func WithSyntax(syn ArgSyntax) RunnerOption {
+	return func(r *runner) {
+		r.syntax = syn
+	}
+}
+
+/*
+WithOutput sets both the output and error streams of the Runner to w.
+It is shorthand for WithIO with only Out and Err set.
+*/
+func WithOutput(w io.Writer) RunnerOption {
+	return WithIO(IOStreams{Err: w, Out: w})
+}
+
+/*
+WithExplain prints what would run to the Runner's output stream instead
+of running it. Each command that would run is listed in order with its
+parsed options, their values and where they came from, and its
+operands. Values are bound to check that they are valid and then
+restored, so an invalid value fails as it would in a real run. Binders
+that do not implement SnapshotBinder keep the value they were given.
+*/
+func WithExplain() RunnerOption {
+	return func(r *runner) {
+		r.explain = true
+	}
+}
+
+/*
+WithExplainFlag adds a built-in "--explain" option accepted after any
+command when parsing GNU syntax. When given, the Runner behaves as with
+WithExplain for that run. An option of the same name defined on a
+Command takes precedence over the built-in one.
+*/
+func WithExplainFlag() RunnerOption {
+	return func(r *runner) {
+		r.explainflag = true
+	}
+}
+
+/*
+WithTrace calls fn with every decision made by the parsing rules, to
+debug how arguments are parsed. Tracing can also be enabled without
+changing code by setting the CLAPR_TRACE environment variable, which
+writes each event to the Runner's error stream.
+*/
+func WithTrace(fn func(event TraceEvent)) RunnerOption {
+	return func(r *runner) {
+		r.tracefn = fn
+	}
+}
+
+/*
+WithVersionFlag adds a built-in "--version" option, also "-V", to the
+root command even if its Version is not set. The option is added
+without this when the root command's Version is set. When parsed,
+ErrVersion is returned with the Version of the root command, or if it
+is not set, the module version, VCS revision, and Go version the
+program was built with.
+*/
+func WithVersionFlag() RunnerOption {
+	return func(r *runner) {
+		r.versionflag = true
+	}
+}
+
+/*
+WithProfilingFlags adds hidden built-in options accepted after any
+command when parsing GNU syntax, for diagnosing the performance of a
+program without rebuilding it:
+
+	--cpuprofile=file  writes a CPU profile while commands run
+	--memprofile=file  writes a heap profile after commands run
+	--trace=file       writes an execution trace while commands run
+	--pprof-addr=addr  serves profiles over HTTP while commands run
+
+Profiles can be read with "go tool pprof" and traces with "go tool
+trace". The HTTP server uses its own handler, so nothing is registered
+on http.DefaultServeMux. Options of the same names defined on a Command
+take precedence over the built-in ones.
+*/
+func WithProfilingFlags() RunnerOption {
+	return func(r *runner) {
+		r.profiling = true
+	}
+}
@@ -0,0 +1,129 @@
+// Copyright 2020 Stephen Buckler. All rights reserved.
+// Use of this source code is governed by a MIT license
+// that can be found in the LICENSE file.
+
+package clapr
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+func (r *runner) pluginDirs() []string {
+	var dirs []string
+
+	if len(r.plugindirs) > 0 {
+		for _, dir := range r.plugindirs {
+			if abs, err := filepath.Abs(dir); err == nil {
+				dirs = append(dirs, abs)
+			}
+		}
+
+		return dirs
+	}
+
+	for _, dir := range filepath.SplitList(r.getenv("PATH")) {
+		if filepath.IsAbs(dir) {
+			dirs = append(dirs, dir)
+		}
+	}
+
+	return dirs
+}
+
+func (r *runner) pluginPrefix() string {
+	if r.progname != "" {
+		return r.progname + "-"
+	}
+
+	if r.root == nil || r.root.Name == "" {
+		return ""
+	}
+
+	return r.root.Name + "-"
+}
+
+func (r *runner) findPlugin(argv []string) (string, bool) {
+	prefix := r.pluginPrefix()
+
+	if !r.plugins || prefix == "" || len(argv) == 0 || argv[0] == "" || strings.HasPrefix(argv[0], "-") {
+		return "", false
+	}
+
+	idx := newCmdIndex(r.root)
+
+	if _, ok := idx.sub(argv[0]); ok || (r.prefix && len(idx.matchPrefix(argv[0])) > 0) {
+		return "", false
+	}
+
+	for _, dir := range r.pluginDirs() {
+		path := filepath.Join(dir, prefix+argv[0])
+
+		if info, err := os.Stat(path); err == nil && isExecutable(info) {
+			return path, true
+		}
+	}
+
+	return "", false
+}
+
+func (r *runner) listPlugins() []string {
+	prefix := r.pluginPrefix()
+	idx := newCmdIndex(r.root)
+	seen := map[string]bool{}
+	var plugins []string
+
+	if !r.plugins || prefix == "" {
+		return nil
+	}
+
+	for _, dir := range r.pluginDirs() {
+		files, err := ioutil.ReadDir(dir)
+
+		if err != nil {
+			continue
+		}
+
+		for _, f := range files {
+			name := strings.TrimPrefix(f.Name(), prefix)
+
+			if !strings.HasPrefix(f.Name(), prefix) || name == "" || seen[name] {
+				continue
+			}
+
+			if info, err := os.Stat(filepath.Join(dir, f.Name())); err != nil || !isExecutable(info) {
+				continue
+			}
+
+			if _, ok := idx.sub(name); ok {
+				continue
+			}
+
+			seen[name] = true
+			plugins = append(plugins, name)
+		}
+	}
+
+	sort.Strings(plugins)
+
+	return plugins
+}
+
+func (r *runner) runPlugin(ctx context.Context, path string, args []string) error {
+	cmd := exec.CommandContext(ctx, path, args...)
+	cmd.Env = r.environ()
+	cmd.Stdin = r.streams.In
+	cmd.Stdout = r.streams.Out
+	cmd.Stderr = r.streams.Err
+
+	return cmd.Run()
+}
+
+func isExecutable(info os.FileInfo) bool {
+	return !info.IsDir() && info.Mode()&0111 != 0
+}
@@ -0,0 +1,293 @@
+// Copyright 2020 Stephen Buckler. All rights reserved.
+// Use of this source code is governed by a MIT license
+// that can be found in the LICENSE file.
+
+//go:build !windows
+// +build !windows
+
+package clapr_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/sebuckler/clapr"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+type testpluginfn func(t *testing.T, name string, dir string)
+
+func TestRunner_Run_Plugins(t *testing.T) {
+	testCases := map[string]testpluginfn{
+		"should run plugin":                     shouldRunPlugin,
+		"should run command over plugin":        shouldRunCmdOverPlugin,
+		"should list plugins in help":           shouldHelpPlugins,
+		"should err when plugin not found":      shouldErrNoPlugin,
+		"should find plugins on PATH":           shouldFindPathPlugin,
+		"should return plugin exit failure":     shouldErrPluginExit,
+		"should pipe plugin io":                 shouldPipePluginIO,
+		"should use plugin environment":         shouldUsePluginEnv,
+		"should explain plugin":                 shouldExplainPlugin,
+		"should list symlinked plugins in help": shouldHelpSymlinkedPlugins,
+		"should skip relative PATH plugins":     shouldSkipRelativePathPlugins,
+		"should run plugin in relative dir":     shouldRunRelativeDirPlugin,
+	}
+
+	for name, test := range testCases {
+		dir, err := ioutil.TempDir("", "clapr")
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		test(t, name, dir)
+		_ = os.RemoveAll(dir)
+	}
+}
+
+func shouldRunPlugin(t *testing.T, name string, dir string) {
+	out := filepath.Join(dir, "out")
+	writePlugin(t, dir, "test-hello", `echo "$@" > "`+out+`"`)
+	os.Args = []string{"test", "hello", "--foo", "bar"}
+	runner := clapr.NewRunner(&clapr.Command{Name: "test"}, clapr.GNU, clapr.WithPlugins(dir))
+
+	if err := runner.Run(context.Background()); err != nil {
+		t.Fail()
+		t.Logf("%s: errored: %v", name, err)
+
+		return
+	}
+
+	if got, _ := ioutil.ReadFile(out); strings.TrimSpace(string(got)) != "--foo bar" {
+		t.Fail()
+		t.Errorf("%s: unexpected plugin args: %s", name, got)
+	}
+}
+
+func shouldRunCmdOverPlugin(t *testing.T, name string, dir string) {
+	writePlugin(t, dir, "test-hello", "exit 1")
+	os.Args = []string{"test", "hello"}
+	ran := false
+	cmd := &clapr.Command{Name: "test"}
+	cmd.AddSubcommand(&clapr.Command{
+		Name: "hello",
+		Run: func(context.Context, []string) {
+			ran = true
+		},
+	})
+	runner := clapr.NewRunner(cmd, clapr.GNU, clapr.WithPlugins(dir))
+
+	if err := runner.Run(context.Background()); err != nil || !ran {
+		t.Fail()
+		t.Logf("%s: did not run command: %v", name, err)
+	}
+}
+
+func shouldHelpPlugins(t *testing.T, name string, dir string) {
+	writePlugin(t, dir, "test-hello", "exit 0")
+	writePlugin(t, dir, "other-bye", "exit 0")
+	os.Args = []string{"test", "-h"}
+	runner := clapr.NewRunner(&clapr.Command{Name: "test"}, clapr.GNU, clapr.WithPlugins(dir))
+	err := runner.Run(context.Background())
+
+	if err == nil || !strings.Contains(err.Error(), "Plugins:\n    [hello]") || strings.Contains(err.Error(), "bye") {
+		t.Fail()
+		t.Logf("%s: plugins not in help: %v", name, err)
+	}
+}
+
+func shouldHelpSymlinkedPlugins(t *testing.T, name string, dir string) {
+	writePlugin(t, dir, "hello.sh", "exit 0")
+
+	if err := ioutil.WriteFile(filepath.Join(dir, "data"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	for link, target := range map[string]string{"test-hello": "hello.sh", "test-data": "data", "test-dir": "."} {
+		if err := os.Symlink(filepath.Join(dir, target), filepath.Join(dir, link)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	os.Args = []string{"test", "-h"}
+	runner := clapr.NewRunner(&clapr.Command{Name: "test"}, clapr.GNU, clapr.WithPlugins(dir))
+	err := runner.Run(context.Background())
+
+	if err == nil || !strings.Contains(err.Error(), "Plugins:\n    [hello]") || strings.Contains(err.Error(), "[d") {
+		t.Fail()
+		t.Logf("%s: symlinked plugin not in help: %v", name, err)
+	}
+}
+
+func shouldErrNoPlugin(t *testing.T, name string, dir string) {
+	os.Args = []string{"test", "hello"}
+	runner := clapr.NewRunner(&clapr.Command{Name: "test"}, clapr.GNU, clapr.WithPlugins(dir))
+
+	if err := runner.Run(context.Background()); err == nil {
+		t.Fail()
+		t.Logf("%s: did not error", name)
+	}
+}
+
+func shouldFindPathPlugin(t *testing.T, name string, dir string) {
+	out := filepath.Join(dir, "out")
+	writePlugin(t, dir, "test-hello", `echo ran > "`+out+`"`)
+	path := os.Getenv("PATH")
+	_ = os.Setenv("PATH", dir+string(os.PathListSeparator)+path)
+	defer os.Setenv("PATH", path)
+	os.Args = []string{"test", "hello"}
+	runner := clapr.NewRunner(&clapr.Command{Name: "test"}, clapr.GNU, clapr.WithPlugins())
+
+	if err := runner.Run(context.Background()); err != nil {
+		t.Fail()
+		t.Logf("%s: errored: %v", name, err)
+	}
+
+	if _, err := os.Stat(out); err != nil {
+		t.Fail()
+		t.Errorf("%s: did not run plugin", name)
+	}
+}
+
+func shouldSkipRelativePathPlugins(t *testing.T, name string, dir string) {
+	writePlugin(t, dir, "test-evil", "exit 0")
+	defer chdir(t, dir)()
+	env := []string{"PATH=/usr/bin::/bin" + string(os.PathListSeparator) + "."}
+	runner := clapr.NewParser(&clapr.Command{Name: "test"}, clapr.GNU, clapr.WithEnv(env), clapr.WithPlugins())
+
+	if result, err := runner.Parse([]string{"evil"}); err == nil {
+		t.Fail()
+		t.Logf("%s: found plugin on relative path: %s", name, result.Plugin)
+	}
+
+	if _, err := runner.Parse([]string{"-h"}); err == nil || strings.Contains(err.Error(), "evil") {
+		t.Fail()
+		t.Logf("%s: listed plugin on relative path: %v", name, err)
+	}
+}
+
+func shouldRunRelativeDirPlugin(t *testing.T, name string, dir string) {
+	out := filepath.Join(dir, "out")
+	writePlugin(t, dir, "test-hello", `echo ran > "`+out+`"`)
+	defer chdir(t, dir)()
+	runner := clapr.NewParser(&clapr.Command{Name: "test"}, clapr.GNU, clapr.WithArgs("hello"), clapr.WithPlugins("."))
+	result, err := runner.Parse([]string{"hello"})
+
+	if err != nil || !filepath.IsAbs(result.Plugin) {
+		t.Fail()
+		t.Logf("%s: plugin not found by absolute path: %v", name, err)
+
+		return
+	}
+
+	if err := runner.Run(context.Background()); err != nil {
+		t.Fail()
+		t.Logf("%s: errored: %v", name, err)
+	}
+
+	if _, err := os.Stat(out); err != nil {
+		t.Fail()
+		t.Errorf("%s: did not run plugin", name)
+	}
+}
+
+func shouldErrPluginExit(t *testing.T, name string, dir string) {
+	writePlugin(t, dir, "test-hello", "exit 3")
+	os.Args = []string{"test", "hello"}
+	runner := clapr.NewRunner(&clapr.Command{Name: "test"}, clapr.GNU, clapr.WithPlugins(dir))
+	var exitErr interface{ ExitCode() int }
+
+	if err := runner.Run(context.Background()); !errors.As(err, &exitErr) || exitErr.ExitCode() != 3 || clapr.ExitCode(err) != 3 {
+		t.Fail()
+		t.Logf("%s: unexpected error: %v", name, err)
+	}
+}
+
+func shouldPipePluginIO(t *testing.T, name string, dir string) {
+	writePlugin(t, dir, "test-hello", "read line; echo \"got $line\"; echo oops >&2")
+	os.Args = []string{"test", "hello"}
+	var out, errout strings.Builder
+	streams := clapr.IOStreams{Err: &errout, In: strings.NewReader("foo\n"), Out: &out}
+	runner := clapr.NewRunner(&clapr.Command{Name: "test"}, clapr.GNU, clapr.WithPlugins(dir), clapr.WithIO(streams))
+
+	if err := runner.Run(context.Background()); err != nil {
+		t.Fail()
+		t.Logf("%s: errored: %v", name, err)
+	}
+
+	if out.String() != "got foo\n" || errout.String() != "oops\n" {
+		t.Fail()
+		t.Errorf("%s: unexpected output: %q, %q", name, out.String(), errout.String())
+	}
+}
+
+func shouldUsePluginEnv(t *testing.T, name string, dir string) {
+	writePlugin(t, dir, "mytool-hello", `echo "$GREETING"`)
+	var out strings.Builder
+	env := []string{"PATH=" + dir, "GREETING=hi"}
+	runner := clapr.NewRunner(
+		&clapr.Command{Name: "test"},
+		clapr.GNU,
+		clapr.WithArgs("hello"),
+		clapr.WithEnv(env),
+		clapr.WithOutput(&out),
+		clapr.WithPlugins(),
+		clapr.WithProgramName("mytool"),
+	)
+
+	if err := runner.Run(context.Background()); err != nil || out.String() != "hi\n" {
+		t.Fail()
+		t.Logf("%s: output: %q, err: %v", name, out.String(), err)
+	}
+}
+
+func shouldExplainPlugin(t *testing.T, name string, dir string) {
+	out := filepath.Join(dir, "out")
+	writePlugin(t, dir, "test-hello", `echo ran > "`+out+`"`)
+	var explained strings.Builder
+	runner := clapr.NewRunner(
+		&clapr.Command{Name: "test"},
+		clapr.GNU,
+		clapr.WithArgs("hello", "--foo"),
+		clapr.WithExplain(),
+		clapr.WithOutput(&explained),
+		clapr.WithPlugins(dir),
+	)
+	expect := fmt.Sprintf("1. plugin %s \"--foo\"\n", filepath.Join(dir, "test-hello"))
+
+	if err := runner.Run(context.Background()); err != nil || explained.String() != expect {
+		t.Fail()
+		t.Logf("%s: output: %q, err: %v", name, explained.String(), err)
+	}
+
+	if _, err := os.Stat(out); err == nil {
+		t.Fail()
+		t.Errorf("%s: ran plugin", name)
+	}
+}
+
+func chdir(t *testing.T, dir string) func() {
+	wd, err := os.Getwd()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+
+	return func() {
+		_ = os.Chdir(wd)
+	}
+}
+
+func writePlugin(t *testing.T, dir string, file string, script string) {
+	if err := ioutil.WriteFile(filepath.Join(dir, file), []byte("#!/bin/sh\n"+script+"\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+}
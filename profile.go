@@ -0,0 +1,196 @@
+// Copyright 2020 Stephen Buckler. All rights reserved.
+// Use of this source code is governed by a MIT license
+// that can be found in the LICENSE file.
+
+package clapr
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"runtime"
+	"runtime/pprof"
+	"runtime/trace"
+	"strconv"
+	"strings"
+	"time"
+)
+
+func (p *parser) newProfilingArgs() []*Arg {
+	flag := func(name string, val *string, usage string) *Arg {
+		return &Arg{
+			Binder:     NewStringArgBinder(val),
+			Hidden:     true,
+			Name:       name,
+			Persistent: true,
+			Usage:      usage,
+		}
+	}
+
+	return []*Arg{
+		flag("cpuprofile", &p.cpuprofile, "write a CPU profile to the file"),
+		flag("memprofile", &p.memprofile, "write a heap profile to the file after running"),
+		flag("trace", &p.exectrace, "write an execution trace to the file"),
+		flag("pprof-addr", &p.pprofaddr, "serve profiles over HTTP at the address"),
+	}
+}
+
+func (p *parser) profile(run func() error) (err error) {
+	closeFile := func(f *os.File) {
+		if closeerr := f.Close(); err == nil {
+			err = closeerr
+		}
+	}
+
+	if p.cpuprofile != "" {
+		f, err := os.Create(p.cpuprofile)
+
+		if err != nil {
+			return err
+		}
+
+		defer closeFile(f)
+
+		if err := pprof.StartCPUProfile(f); err != nil {
+			return err
+		}
+
+		defer pprof.StopCPUProfile()
+	}
+
+	if p.exectrace != "" {
+		f, err := os.Create(p.exectrace)
+
+		if err != nil {
+			return err
+		}
+
+		defer closeFile(f)
+
+		if err := trace.Start(f); err != nil {
+			return err
+		}
+
+		defer trace.Stop()
+	}
+
+	if p.pprofaddr != "" {
+		ln, err := net.Listen("tcp", p.pprofaddr)
+
+		if err != nil {
+			return err
+		}
+
+		srv := &http.Server{Handler: newProfileMux()}
+
+		defer srv.Close()
+
+		go func() {
+			_ = srv.Serve(ln)
+		}()
+
+		fmt.Fprintf(p.streams.Err, "clapr: serving profiles at http://%s/debug/pprof/\n", ln.Addr())
+	}
+
+	if p.memprofile != "" {
+		defer func() {
+			f, createrr := os.Create(p.memprofile)
+
+			if createrr != nil {
+				if err == nil {
+					err = createrr
+				}
+
+				return
+			}
+
+			defer closeFile(f)
+
+			runtime.GC()
+
+			if writerr := pprof.WriteHeapProfile(f); err == nil {
+				err = writerr
+			}
+		}()
+	}
+
+	return run()
+}
+
+func newProfileMux() *http.ServeMux {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("/debug/pprof/", func(w http.ResponseWriter, r *http.Request) {
+		name := strings.TrimPrefix(r.URL.Path, "/debug/pprof/")
+		seconds, _ := strconv.Atoi(r.FormValue("seconds"))
+		debug, _ := strconv.Atoi(r.FormValue("debug"))
+
+		switch name {
+		case "":
+			w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+
+			for _, prof := range pprof.Profiles() {
+				fmt.Fprintf(w, "%s %d\n", prof.Name(), prof.Count())
+			}
+
+			fmt.Fprintln(w, "profile")
+			fmt.Fprintln(w, "trace")
+		case "profile":
+			if seconds <= 0 {
+				seconds = 30
+			}
+
+			w.Header().Set("Content-Type", "application/octet-stream")
+
+			if err := pprof.StartCPUProfile(w); err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+
+				return
+			}
+
+			sleep(r, time.Duration(seconds)*time.Second)
+			pprof.StopCPUProfile()
+		case "trace":
+			if seconds <= 0 {
+				seconds = 1
+			}
+
+			w.Header().Set("Content-Type", "application/octet-stream")
+
+			if err := trace.Start(w); err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+
+				return
+			}
+
+			sleep(r, time.Duration(seconds)*time.Second)
+			trace.Stop()
+		default:
+			prof := pprof.Lookup(name)
+
+			if prof == nil {
+				http.NotFound(w, r)
+
+				return
+			}
+
+			if debug > 0 {
+				w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+			} else {
+				w.Header().Set("Content-Type", "application/octet-stream")
+			}
+
+			_ = prof.WriteTo(w, debug)
+		}
+	})
+
+	return mux
+}
+
+func sleep(r *http.Request, d time.Duration) {
+	select {
+	case <-time.After(d):
+	case <-r.Context().Done():
+	}
+}
@@ -0,0 +1,87 @@
+// Copyright 2020 Stephen Buckler. All rights reserved.
+// Use of this source code is governed by a MIT license
+// that can be found in the LICENSE file.
+
+package clapr
+
+/*
+ParseResult describes what a Runner parsed from a list of arguments
+without running anything. It is returned by Parse and run by Execute.
+Changing its fields does not change what Execute runs.
+*/
+type ParseResult struct {
+	Commands   []*ParsedCommand // Parsed commands in the order they appeared
+	Plugin     string           // Path of the plugin executable run instead of any command
+	PluginArgs []string         // Arguments passed to the plugin executable
+	parser     *parser
+}
+
+/*
+ParsedCommand is a single command parsed from a list of arguments.
+*/
+type ParsedCommand struct {
+	Command  *Command        // Definition of the parsed command
+	Operands []string        // Operands parsed for the command
+	Options  []*ParsedOption // Options parsed for the command, in order
+	Path     string          // Names of the command and its parents, as shown in help text
+}
+
+/*
+ParsedOption is a single option parsed for a command.
+*/
+type ParsedOption struct {
+	Arg    *Arg        // Definition of the parsed option
+	Raw    string      // Argument the option was parsed from, such as "--name=foo"
+	Source ValueSource // Where the argument came from
+	Value  string      // Option-argument to be bound, empty if none was given
+}
+
+/*
+ValueSource identifies where a parsed argument came from.
+*/
+type ValueSource int
+
+const (
+	SourceArgs  ValueSource = iota // Arguments given to the Runner
+	SourceAlias                    // Expansion of an alias set with WithAliases
+)
+
+/*
+String returns a short description of the source.
+*/
+func (s ValueSource) String() string {
+	switch s {
+	case SourceArgs:
+		return "command line"
+	case SourceAlias:
+		return "alias"
+	}
+
+	return "unknown"
+}
+
+func (p *parser) result() *ParseResult {
+	result := &ParseResult{parser: p}
+
+	for _, cmd := range p.parsed {
+		parsed := &ParsedCommand{
+			Command:  cmd.cmddef,
+			Operands: append([]string{}, cmd.operands...),
+			Options:  []*ParsedOption{},
+			Path:     cmdPath(cmd.cmddef, p.rootName()),
+		}
+
+		for _, arg := range cmd.parsedargs {
+			parsed.Options = append(parsed.Options, &ParsedOption{
+				Arg:    arg.argdef,
+				Raw:    arg.raw,
+				Source: arg.source,
+				Value:  arg.val,
+			})
+		}
+
+		result.Commands = append(result.Commands, parsed)
+	}
+
+	return result
+}
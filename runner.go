@@ -8,8 +8,12 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io"
 	"os"
+	"sort"
 	"strings"
+	"sync/atomic"
+	"time"
 	"unicode"
 )
 
@@ -20,19 +24,73 @@ type Runner interface {
 	// Run will parse the command line arguments and return an error if
 	// the parsing fails for any reason. If parsing is successful, Run
 	// will then execute the commands it parsed. It accepts a Context
-	// that it passes to each command's Run function. If a command
-	// fails to execute for any reason, Run will return the error.
+	// that it passes to each command's Run function. If a command's
+	// RunE function or one of its hooks returns an error, no further
+	// commands are executed and Run returns the error.
 	Run(ctx context.Context) error
 }
 
+/*
+Parser is a Runner that can also parse and execute in separate steps,
+so what was parsed can be inspected before it is run.
+*/
+type Parser interface {
+	Runner
+
+	// Parse parses the arguments given, not including the program
+	// name, and returns what was parsed without binding any values or
+	// running any commands. It returns the same errors as Run does for
+	// parsing. Values are only checked when Execute binds them, so a
+	// value its binder rejects is reported by Execute, not Parse.
+	Parse(argv []string) (*ParseResult, error)
+
+	// Execute binds the values and runs the commands of a ParseResult
+	// returned by the same Parser's Parse method, in the same way as
+	// Run does. A ParseResult can only be executed once.
+	Execute(ctx context.Context, result *ParseResult) error
+}
+
 type runner struct {
-	argctx   *parsedArgContext
-	argv     []string
-	cmdctx   *parsedCmdContext
-	helpmode bool
-	parsed   []*parsedCmd
-	root     *Command
-	syntax   ArgSyntax
+	aliases     map[string]string
+	argv        []string
+	chain       ChainMode
+	cleanup     []func()
+	env         []string
+	explain     bool
+	explainflag bool
+	middleware  []Middleware
+	plugindirs  []string
+	plugins     bool
+	policy      RunPolicy
+	prefix      bool
+	profiling   bool
+	progname    string
+	root        *Command
+	signals     bool
+	streams     *IOStreams
+	syntax      ArgSyntax
+	timeoutflag bool
+	tracefn     func(event TraceEvent)
+	versionflag bool
+}
+
+type parser struct {
+	*runner
+	aliased    int
+	argv       []string
+	builtins   []*Arg
+	cmdctx     *parsedCmdContext
+	cpuprofile string
+	exectrace  string
+	executed   int32
+	explainarg *Arg
+	indexes    map[*Command]*cmdIndex
+	memprofile string
+	parsed     []*parsedCmd
+	pprofaddr  string
+	timeout    time.Duration
+	tracew     io.Writer
+	versionarg *Arg
 }
 
 /*
@@ -46,209 +104,444 @@ const (
 	POSIX                  // POSIX-2017.1 syntax
 )
 
+/*
+RunPolicy determines which of the parsed commands are executed.
+Persistent hooks are run regardless of the policy.
+*/
+type RunPolicy int
+
+const (
+	RunChain    RunPolicy = iota // Run every parsed command from the root down
+	RunLeaf                      // Run only parsed commands with no parsed subcommands
+	RunExplicit                  // Run the chain except Passthrough commands with a parsed subcommand
+)
+
+/*
+ChainMode determines how sibling subcommands parsed from the same
+command line, such as "tool build lint test", are executed.
+*/
+type ChainMode int
+
+const (
+	ChainDefault    ChainMode = iota // Run sibling commands one after another without wrapping errors
+	ChainSequential                  // Run sibling commands one after another
+	ChainParallel                    // Run sibling commands concurrently
+)
+
 type parsedArg struct {
-	argdef *Arg
-	raw    string
-	val    string
+	argdef   *Arg
+	raw      string
+	required bool
+	source   ValueSource
+	val      string
 }
 
 type parsedCmd struct {
 	args       []string
 	cmddef     *Command
-	index      int
 	operands   []string
 	parsedargs []*parsedArg
+	sources    []ValueSource
+	start      int
 	subcmds    []*Command
 }
 
 type parsedCmdContext struct {
 	argsraw    []string
+	cur        *Command
 	parsed     []*parsedCmd
-	path       []*Command
+	persistent map[*Arg]bool
 	terminated bool
 }
 
 type parsedArgContext struct {
-	args       []*Arg
+	builtins   *cmdIndex
+	index      *cmdIndex
 	last       *parsedArg
 	operands   []string
 	parsed     []*parsedArg
+	persistent map[*Arg]bool
+	seen       map[*Arg]bool
+	source     ValueSource
 	terminated bool
 }
 
 type argRuleFn func(arg *string, i int, ctx *parsedArgContext) (bool, error)
 
+type argRule struct {
+	fn   argRuleFn
+	name string
+}
+
 /*
 NewRunner creates a struct that satisfies the Runner interface. It
 accepts a Command and ArgSyntax to determine how to parse and execute
-the command line arguments.
+the command line arguments. Any RunnerOption values are applied in
+order. Neither the runner nor parsing modify the Command tree, so one
+tree may be shared by runners running concurrently.
 */
-func NewRunner(cmd *Command, syn ArgSyntax) Runner {
-	configureArgs(cmd)
+func NewRunner(cmd *Command, syn ArgSyntax, opts ...RunnerOption) Runner {
+	return newRunner(cmd, syn, opts...)
+}
+
+/*
+NewParser creates a struct that satisfies the Parser interface. It
+accepts the same arguments as NewRunner, and the Runner that NewRunner
+returns also satisfies Parser.
+*/
+func NewParser(cmd *Command, syn ArgSyntax, opts ...RunnerOption) Parser {
+	return newRunner(cmd, syn, opts...)
+}
 
-	return &runner{
-		argv:   os.Args[1:],
-		parsed: []*parsedCmd{},
-		syntax: syn,
-		root:   cmd,
+func newRunner(cmd *Command, syn ArgSyntax, opts ...RunnerOption) *runner {
+	r := &runner{
+		argv:    os.Args[1:],
+		root:    cmd,
+		streams: newIOStreams(),
+		syntax:  syn,
 	}
+
+	for _, opt := range opts {
+		opt(r)
+	}
+
+	return r
 }
 
 func (r *runner) Run(ctx context.Context) error {
+	result, err := r.Parse(r.argv)
+
+	if err != nil {
+		return err
+	}
+
+	return r.Execute(ctx, result)
+}
+
+func (r *runner) Parse(argv []string) (*ParseResult, error) {
 	if r.root == nil {
-		return fmt.Errorf("root command not set")
+		return nil, fmt.Errorf("root command not set")
 	}
 
-	if err := r.parse(); err != nil {
-		return err
+	if r.isMultiCall() {
+		argv = append([]string{r.progname}, argv...)
+	}
+
+	argv, aliased, err := r.expandAliases(argv)
+
+	if err != nil {
+		return nil, usageError(err)
+	}
+
+	p := &parser{
+		aliased: aliased,
+		argv:    argv,
+		parsed:  []*parsedCmd{},
+		runner:  r,
 	}
 
-	if len(r.parsed) == 0 {
-		return fmt.Errorf("no commands parsed")
+	if path, ok := r.findPlugin(argv); ok {
+		return &ParseResult{Plugin: path, PluginArgs: argv[1:], parser: p}, nil
+	}
+
+	p.builtins = p.newBuiltinArgs()
+	p.tracew = p.traceWriter()
+
+	if err := p.parse(); err != nil {
+		return nil, usageError(err)
+	}
+
+	if len(p.parsed) == 0 {
+		return nil, fmt.Errorf("no commands parsed")
+	}
+
+	return p.result(), nil
+}
+
+func (r *runner) Execute(ctx context.Context, result *ParseResult) error {
+	if result == nil || result.parser == nil || result.parser.runner != r {
+		return fmt.Errorf("parse result not returned by this runner")
+	}
+
+	if !atomic.CompareAndSwapInt32(&result.parser.executed, 0, 1) {
+		return fmt.Errorf("parse result already executed")
 	}
 
 	if ctx == nil {
 		ctx = context.Background()
 	}
 
-	for _, cmd := range r.parsed {
-		if cmd.cmddef.Run == nil {
-			continue
-		}
+	ctx = context.WithValue(ctx, ioKey{}, r.streams)
 
-		if err := ctx.Err(); err != nil {
-			return err
+	if r.env != nil {
+		ctx = context.WithValue(ctx, envKey{}, r.env)
+	}
+
+	if r.signals {
+		var stop func()
+		ctx, stop = r.notifyContext(ctx)
+
+		defer stop()
+	}
+
+	p := result.parser
+
+	if result.Plugin != "" && r.explain {
+		return p.explainPlugin(r.streams.Out, result.Plugin)
+	}
+
+	if result.Plugin != "" {
+		return r.runPlugin(ctx, result.Plugin, p.argv[1:])
+	}
+
+	if p.shouldExplain() {
+		restore, err := p.bindParsed()
+
+		if err != nil {
+			return usageError(err)
 		}
 
-		cmd.cmddef.Run(ctx, cmd.operands)
+		restore()
+
+		return p.explainTree(r.streams.Out, newExecTree(p.parsed))
 	}
 
-	return nil
+	if _, err := p.bindParsed(); err != nil {
+		return usageError(err)
+	}
+
+	return p.profile(func() error {
+		return p.chainError(p.exec(ctx, newExecTree(p.parsed)))
+	})
 }
 
-func (r *runner) parse() error {
-	r.parseCommands()
+func (p *parser) parse() error {
+	if err := p.parseCommands(); err != nil {
+		return fmt.Errorf("%v\n%w", err, &ErrHelp{Help: p.help(p.cmdctx.cur)})
+	}
 
-	for _, cmd := range r.cmdctx.parsed {
-		if r.cmdctx.terminated {
+	for _, cmd := range p.cmdctx.parsed {
+		if p.cmdctx.terminated {
 			break
 		}
 
-		if err := r.parseArgs(cmd); err != nil {
+		if err := p.parseArgs(cmd); err != nil {
 			var errhelp *ErrHelp
+			var errversion *ErrVersion
 
-			if ok := errors.As(err, &errhelp); ok {
+			if errors.As(err, &errhelp) || errors.As(err, &errversion) {
 				return err
 			}
 
-			return fmt.Errorf("%v\n%w", err, &ErrHelp{Help: r.getHelpMsg(cmd)})
+			return fmt.Errorf("%v\n%w", err, &ErrHelp{Help: p.getHelpMsg(cmd)})
 		}
 
-		r.parsed = append(r.parsed, cmd)
+		p.parsed = append(p.parsed, cmd)
 	}
 
 	return nil
 }
 
-func (r *runner) parseCommands() {
+func (p *parser) parseCommands() error {
 	last := &parsedCmd{
 		args:   []string{},
-		cmddef: r.root,
+		cmddef: p.root,
 	}
-	r.cmdctx = &parsedCmdContext{
-		parsed: []*parsedCmd{last},
-		path:   r.root.subcmds,
+	p.cmdctx = &parsedCmdContext{
+		cur:        p.root,
+		parsed:     []*parsedCmd{last},
+		persistent: map[*Arg]bool{},
 	}
 
-	for i, a := range r.argv {
-		if cmd := r.walk(a); cmd != nil {
-			last = r.addParsedCmd(cmd, i)
+	var operand, terminated, value bool
+
+	for i, a := range p.argv {
+		parent := p.cmdctx.cur
+		cmd, err := p.walk(a, !operand && !terminated && !value)
+
+		if cmd != nil || err != nil {
+			p.trace(parent, "subcommand", a, cmd != nil, err)
+		}
+
+		if err != nil {
+			return err
+		}
+
+		if cmd != nil {
+			last = p.addParsedCmd(cmd, i)
+			operand, value = false, false
 
 			continue
 		}
 
 		last.args = append(last.args, a)
+		last.sources = append(last.sources, p.source(i))
+
+		switch {
+		case terminated:
+		case value:
+			value = false
+		case a == "--":
+			terminated = true
+		case strings.HasPrefix(a, "-") && len(a) > 1:
+			value = p.takesValue(parent, a)
+		default:
+			operand = true
+		}
+	}
+
+	return nil
+}
+
+func (p *parser) takesValue(cmd *Command, opt string) bool {
+	argctx := &parsedArgContext{
+		builtins: p.builtinIndex(cmd),
+		index:    p.index(cmd),
+	}
+
+	if strings.HasPrefix(opt, "--") {
+		a, ok := argctx.longArg(strings.TrimPrefix(opt, "--"))
+
+		return p.syntax == GNU && ok && a.Required && !isBoolArg(a)
 	}
+
+	shorts := []rune(strings.TrimPrefix(opt, "-"))
+
+	for i, ch := range shorts {
+		a, ok := argctx.shortArg(ch)
+
+		if !ok {
+			return false
+		}
+
+		if !isBoolArg(a) {
+			return i == len(shorts)-1
+		}
+	}
+
+	return false
+}
+
+func (p *parser) source(index int) ValueSource {
+	if index < p.aliased {
+		return SourceAlias
+	}
+
+	return SourceArgs
 }
 
-func (r *runner) walk(arg string) *Command {
-	for _, cmd := range r.cmdctx.path {
-		if arg == cmd.Name {
-			r.updatePath(cmd)
+func (p *parser) index(cmd *Command) *cmdIndex {
+	idx, ok := p.indexes[cmd]
 
-			return cmd
+	if !ok {
+		if p.indexes == nil {
+			p.indexes = map[*Command]*cmdIndex{}
 		}
+
+		idx = newCmdIndex(cmd)
+		p.indexes[cmd] = idx
 	}
 
-	return nil
+	return idx
 }
 
-func (r *runner) updatePath(cmd *Command) {
-	cmdpath := append([]*Command{}, cmd.subcmds...)
-	parent := cmd.parent
+func (p *parser) walk(arg string, prefix bool) (*Command, error) {
+	for c := p.cmdctx.cur; c != nil; c = c.parent {
+		if sub, ok := p.index(c).sub(arg); ok && sub.resolve(c) != nil {
+			p.cmdctx.cur = sub.cmd
 
-	for parent != nil {
-		cmdpath = append(cmdpath, parent.subcmds...)
-		parent = parent.parent
+			return sub.cmd, nil
+		}
 	}
 
-	r.cmdctx.path = cmdpath
+	if !prefix || !p.prefix || arg == "" || strings.HasPrefix(arg, "-") {
+		return nil, nil
+	}
+
+	cur := p.cmdctx.cur
+	matches := p.index(cur).matchPrefix(arg)
+
+	if len(matches) == 1 && matches[0].resolve(cur) != nil {
+		p.cmdctx.cur = matches[0].cmd
+
+		return matches[0].cmd, nil
+	}
+
+	if len(matches) > 1 {
+		var names []string
+
+		for _, m := range matches {
+			names = append(names, m.name)
+		}
+
+		sort.Strings(names)
+
+		return nil, fmt.Errorf("ambiguous command: %s could be %s", arg, strings.Join(names, ", "))
+	}
+
+	return nil, nil
 }
 
-func (r *runner) addParsedCmd(cmd *Command, index int) *parsedCmd {
-	for _, p := range r.cmdctx.parsed {
-		if cmd.parent == p.cmddef {
-			p.subcmds = append(p.subcmds, cmd)
+func (p *parser) addParsedCmd(cmd *Command, index int) *parsedCmd {
+	for _, prev := range p.cmdctx.parsed {
+		if cmd.parent == prev.cmddef {
+			prev.subcmds = append(prev.subcmds, cmd)
 		}
 	}
 
 	parsed := &parsedCmd{
 		args:   []string{},
 		cmddef: cmd,
-		index:  index,
+		start:  index + 1,
 	}
-	r.cmdctx.parsed = append(r.cmdctx.parsed, parsed)
+	p.cmdctx.parsed = append(p.cmdctx.parsed, parsed)
 
 	return parsed
 }
 
-func (r *runner) parseArgs(cmd *parsedCmd) error {
-	switch r.syntax {
+func (p *parser) parseArgs(cmd *parsedCmd) error {
+	switch p.syntax {
 	case GNU:
-		return r.parseArgRules(cmd, getGnuRules())
+		return p.parseArgRules(cmd, getGnuRules())
 	case POSIX:
-		return r.parseArgRules(cmd, getPosixRules())
+		return p.parseArgRules(cmd, getPosixRules())
 	}
 
 	return fmt.Errorf("unsupported argument parsing syntax")
 }
 
-func (r *runner) parseArgRules(cmd *parsedCmd, rulefn []argRuleFn) error {
-	r.argctx = &parsedArgContext{
-		args:     cmd.cmddef.Args,
-		operands: []string{},
-		parsed:   []*parsedArg{},
+func (p *parser) parseArgRules(cmd *parsedCmd, rules []argRule) error {
+	argctx := &parsedArgContext{
+		builtins:   p.builtinIndex(cmd.cmddef),
+		index:      p.index(cmd.cmddef),
+		operands:   []string{},
+		parsed:     []*parsedArg{},
+		persistent: p.cmdctx.persistent,
+		seen:       map[*Arg]bool{},
 	}
 
 	for i, arg := range cmd.args {
 		var skip bool
 		var err error
 
-		if r.cmdctx.terminated {
+		if p.cmdctx.terminated {
 			break
 		}
 
-		for _, rule := range rulefn {
-			if skip, err = rule(&arg, i, r.argctx); err != nil {
+		argctx.source = cmd.sources[i]
+
+		for _, rule := range rules {
+			token := arg
+			skip, err = rule.fn(&arg, i, argctx)
+			p.trace(cmd.cmddef, rule.name, token, skip, err)
+
+			if err != nil {
 				var errterm *errTerm
 
 				if ok := errors.As(err, &errterm); ok {
-					argpos := cmd.index + errterm.index
-					r.cmdctx.terminated = true
-
-					if len(cmd.args[argpos]) > 1 {
-						r.argctx.operands = append(r.argctx.operands, r.argv[argpos+1:]...)
-					}
+					argpos := cmd.start + errterm.index
+					p.cmdctx.terminated = true
+					argctx.operands = append(argctx.operands, p.argv[argpos+1:]...)
 
 					break
 				}
@@ -266,57 +559,96 @@ func (r *runner) parseArgRules(cmd *parsedCmd, rulefn []argRuleFn) error {
 		return fmt.Errorf("unknown argument provided: %s", arg)
 	}
 
-	cmd.parsedargs = r.argctx.parsed
-	cmd.operands = r.argctx.operands
+	cmd.parsedargs = argctx.parsed
+	cmd.operands = argctx.operands
 
-	return r.bindArgs(cmd)
+	return p.validateArgs(cmd)
 }
 
-func (r *runner) bindArgs(cmd *parsedCmd) error {
+func (p *parser) validateArgs(cmd *parsedCmd) error {
 	for _, arg := range cmd.parsedargs {
 		if arg.argdef.IsHelp {
-			return &ErrHelp{r.getHelpMsg(cmd)}
+			return &ErrHelp{p.getHelpMsg(cmd)}
+		}
+
+		if arg.argdef == p.versionarg {
+			return &ErrVersion{Version: p.versionText()}
 		}
 
 		if reqerr := validateReqArg(arg); reqerr != nil {
 			return reqerr
 		}
+	}
 
-		if arg.argdef.Binder == nil {
-			continue
+	if cmd.cmddef.Operands != nil {
+		return cmd.cmddef.Operands(cmd.operands)
+	}
+
+	return nil
+}
+
+func (p *parser) bindParsed() (func(), error) {
+	var snapshots []func()
+
+	restore := func() {
+		for i := len(snapshots) - 1; i >= 0; i-- {
+			snapshots[i]()
 		}
+	}
+
+	for _, cmd := range p.parsed {
+		for _, arg := range cmd.parsedargs {
+			if arg.argdef.Binder == nil {
+				continue
+			}
+
+			if snap, ok := arg.argdef.Binder.(SnapshotBinder); ok {
+				snapshots = append(snapshots, snap.Snapshot())
+			}
 
-		if binderr := arg.argdef.Binder.Bind(arg.raw, arg.val); binderr != nil {
-			return binderr
+			if binderr := arg.argdef.Binder.Bind(arg.raw, arg.val); binderr != nil {
+				restore()
+
+				return nil, fmt.Errorf("%v\n%w", binderr, &ErrHelp{Help: p.getHelpMsg(cmd)})
+			}
 		}
 	}
 
-	return nil
+	return restore, nil
+}
+
+func (p *parser) getHelpMsg(cmd *parsedCmd) string {
+	return p.help(cmd.cmddef)
 }
 
-func (r *runner) getHelpMsg(cmd *parsedCmd) string {
-	if cmd.cmddef.helper != nil {
-		return cmd.cmddef.helper.Help(r.syntax)
+func (p *parser) help(cmd *Command) string {
+	h := &helper{
+		builtins: p.builtinArgs(cmd),
+		root:     p.rootName(),
 	}
 
-	return ""
+	if cmd == p.root {
+		h.plugins = p.listPlugins()
+	}
+
+	return cmd.help(p.syntax, h)
 }
 
-func getGnuRules() []argRuleFn {
-	return []argRuleFn{
-		gnuTerminated,
-		posixTerminated,
-		validGnuOpt,
-		gnuOpt,
-		posixOperand,
-		gnuOptArg,
-		posixOpt,
-		posixOptArg,
+func getGnuRules() []argRule {
+	return []argRule{
+		{fn: gnuTerminated, name: "gnuTerminated"},
+		{fn: posixTerminated, name: "posixTerminated"},
+		{fn: validGnuOpt, name: "validGnuOpt"},
+		{fn: gnuOpt, name: "gnuOpt"},
+		{fn: posixOperand, name: "posixOperand"},
+		{fn: gnuOptArg, name: "gnuOptArg"},
+		{fn: posixOpt, name: "posixOpt"},
+		{fn: posixOptArg, name: "posixOptArg"},
 	}
 }
 
 func gnuTerminated(arg *string, i int, ctx *parsedArgContext) (bool, error) {
-	if *arg == "--" && ctx.last != nil && !ctx.last.argdef.Required && ctx.last.val == "" {
+	if *arg == "--" && ctx.last != nil && !ctx.last.required && ctx.last.val == "" {
 		return true, &errTerm{index: i}
 	}
 
@@ -341,30 +673,28 @@ func gnuOpt(arg *string, _ int, ctx *parsedArgContext) (bool, error) {
 	opt = optparts[0]
 	optarg := strings.Join(optparts[1:], "=")
 
-	for _, a := range ctx.args {
-		if opt != a.Name {
-			continue
-		}
+	a, ok := ctx.longArg(opt)
 
-		for _, namepart := range strings.Split(a.Name, "-") {
-			for _, ch := range namepart {
-				if !isValidPosixName(string(ch), ch) {
-					return false, fmt.Errorf("invalid option name: --%s", opt)
-				}
-			}
-		}
+	if !ok {
+		return false, nil
+	}
 
-		if !isValidRptArg(ctx, opt) {
-			return false, fmt.Errorf("non-repeatable option: --%s", opt)
+	for _, namepart := range strings.Split(a.Name, "-") {
+		for _, ch := range namepart {
+			if !isValidPosixName(string(ch), ch) {
+				return false, fmt.Errorf("invalid option name: --%s", opt)
+			}
 		}
+	}
 
-		updateArgCtx(a, *arg, ctx)
-		ctx.last.val = optarg
-
-		return true, nil
+	if !isValidRptArg(ctx, a) {
+		return false, fmt.Errorf("non-repeatable option: --%s", opt)
 	}
 
-	return false, nil
+	updateArgCtx(a, *arg, ctx)
+	ctx.last.val = optarg
+
+	return true, nil
 }
 
 func gnuOptArg(arg *string, _ int, ctx *parsedArgContext) (bool, error) {
@@ -377,7 +707,7 @@ func gnuOptArg(arg *string, _ int, ctx *parsedArgContext) (bool, error) {
 			continue
 		}
 
-		if !a.argdef.Required && a.val == "" {
+		if !a.required && a.val == "" {
 			return false, fmt.Errorf(
 				"optional option-argument '%s' must be provided with option '--%s' separated by '='",
 				*arg, a.argdef.Name,
@@ -392,13 +722,13 @@ func gnuOptArg(arg *string, _ int, ctx *parsedArgContext) (bool, error) {
 	return false, nil
 }
 
-func getPosixRules() []argRuleFn {
-	return []argRuleFn{
-		posixTerminated,
-		validPosixOpt,
-		posixOpt,
-		posixOperand,
-		posixOptArg,
+func getPosixRules() []argRule {
+	return []argRule{
+		{fn: posixTerminated, name: "posixTerminated"},
+		{fn: validPosixOpt, name: "validPosixOpt"},
+		{fn: posixOpt, name: "posixOpt"},
+		{fn: posixOperand, name: "posixOperand"},
+		{fn: posixOptArg, name: "posixOptArg"},
 	}
 }
 
@@ -429,24 +759,20 @@ func posixOpt(arg *string, _ int, ctx *parsedArgContext) (bool, error) {
 
 	for i, ch := range opt {
 		name := string(ch)
+		a, ok := ctx.shortArg(ch)
+		parsed = ok
 
-		for _, a := range ctx.args {
-			if name != a.Name && ch != a.ShortName {
-				parsed = false
-
-				continue
-			}
-
+		if ok {
 			if !isValidPosixName(a.Name, a.ShortName) {
 				return false, fmt.Errorf("invalid option name: -%s", opt)
 			}
 
-			if !isValidRptArg(ctx, name) {
+			if !isValidRptArg(ctx, a) {
 				return false, fmt.Errorf("non-repeatable option: -%s", opt)
 			}
 
-			a.Required, parsed = true, true
 			updateArgCtx(a, *arg, ctx)
+			ctx.last.required = true
 			rest, *arg = strings.TrimPrefix(rest, name), rest
 
 			if !isBoolArg(a) && len(opt[i:]) > 1 {
@@ -454,8 +780,6 @@ func posixOpt(arg *string, _ int, ctx *parsedArgContext) (bool, error) {
 
 				return true, nil
 			}
-
-			break
 		}
 
 		if i == 0 && !parsed {
@@ -497,22 +821,23 @@ func posixOptArg(arg *string, _ int, ctx *parsedArgContext) (bool, error) {
 
 func updateArgCtx(arg *Arg, raw string, ctx *parsedArgContext) {
 	parsed := &parsedArg{
-		argdef: arg,
-		raw:    raw,
-		val:    "",
+		argdef:   arg,
+		raw:      raw,
+		required: arg.Required,
+		source:   ctx.source,
+		val:      "",
 	}
 	ctx.last = parsed
 	ctx.parsed = append(ctx.parsed, parsed)
-}
+	ctx.seen[arg] = true
 
-func isValidRptArg(ctx *parsedArgContext, opt string) bool {
-	for _, p := range ctx.parsed {
-		if (opt == p.argdef.Name || opt == string(p.argdef.ShortName)) && !p.argdef.Repeatable {
-			return false
-		}
+	if arg.Persistent {
+		ctx.persistent[arg] = true
 	}
+}
 
-	return true
+func isValidRptArg(ctx *parsedArgContext, arg *Arg) bool {
+	return arg.Repeatable || (!ctx.seen[arg] && !(arg.Persistent && ctx.persistent[arg]))
 }
 
 func isValidPosixName(long string, short rune) bool {
@@ -523,7 +848,7 @@ func isValidPosixName(long string, short rune) bool {
 }
 
 func validateReqArg(arg *parsedArg) error {
-	if !isBoolArg(arg.argdef) && arg.argdef.Required && arg.val == "" {
+	if !isBoolArg(arg.argdef) && arg.required && arg.val == "" {
 		return fmt.Errorf("missing option-argument for required option: %s", arg.argdef.Name)
 	}
 
@@ -0,0 +1,75 @@
+// Copyright 2020 Stephen Buckler. All rights reserved.
+// Use of this source code is governed by a MIT license
+// that can be found in the LICENSE file.
+
+package clapr_test
+
+import (
+	"context"
+	"fmt"
+	"github.com/sebuckler/clapr"
+	"os"
+	"testing"
+)
+
+func BenchmarkRunner_Run(b *testing.B) {
+	benchCases := []struct {
+		cmds int
+		opts int
+	}{
+		{cmds: 1, opts: 1},
+		{cmds: 10, opts: 10},
+		{cmds: 100, opts: 50},
+		{cmds: 400, opts: 100},
+		{cmds: 400, opts: 500},
+	}
+
+	for _, bc := range benchCases {
+		for _, syn := range []clapr.ArgSyntax{clapr.GNU, clapr.POSIX} {
+			name := fmt.Sprintf("%s/cmds=%d/opts=%d", getSynName(syn), bc.cmds, bc.opts)
+
+			b.Run(name, func(b *testing.B) {
+				benchRun(b, syn, bc.cmds, bc.opts)
+			})
+		}
+	}
+}
+
+func benchRun(b *testing.B, syn clapr.ArgSyntax, cmds int, opts int) {
+	root := &clapr.Command{Name: "bench"}
+	last := fmt.Sprintf("cmd%d", cmds-1)
+	lastopt := fmt.Sprintf("opt%d", opts-1)
+
+	for i := 0; i < cmds; i++ {
+		root.AddSubcommand(&clapr.Command{Args: getBenchArgs(opts), Name: fmt.Sprintf("cmd%d", i)})
+	}
+
+	os.Args = []string{"bench", last, "--" + lastopt + "=val", "operand"}
+
+	if syn == clapr.POSIX {
+		os.Args = []string{"bench", last, "-y", "val", "operand"}
+	}
+
+	ctx := context.Background()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		if err := clapr.NewRunner(root, syn).Run(ctx); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func getBenchArgs(count int) []*clapr.Arg {
+	args := make([]*clapr.Arg, count)
+
+	for i := range args {
+		args[i] = &clapr.Arg{Name: fmt.Sprintf("opt%d", i)}
+	}
+
+	args[count-1].ShortName = 'y'
+
+	return args
+}
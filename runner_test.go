@@ -10,24 +10,111 @@ import (
 	"fmt"
 	"github.com/sebuckler/clapr"
 	"github.com/sebuckler/clapr/testclapr"
+	"io/ioutil"
+	"net/http"
 	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 )
 
 type testrunfn func(t *testing.T, name string, syn clapr.ArgSyntax)
 
+type testSnapshotBinder struct {
+	val *string
+}
+
+func (b *testSnapshotBinder) Bind(_ string, val string) error {
+	*(b.val) = val
+
+	return nil
+}
+
+func (b *testSnapshotBinder) Snapshot() func() {
+	prev := *(b.val)
+
+	return func() { *(b.val) = prev }
+}
+
 func TestRunner_Run(t *testing.T) {
 	testCases := map[string]testrunfn{
-		"should err when no command provided": shouldErrNilCmd,
-		"should err when no args provided":    shouldErrNoArgs,
-		"should err when help arg provided":   shouldErrHelpArg,
-		"should err when args invalid":        shouldErrArgsInvalid,
-		"should err when arg bind fails":      shouldErrArgBind,
-		"should err when syntax unsupported":  shouldErrSyntax,
-		"should err when run with ctx err":    shouldErrCtxCanceled,
-		"should parse operands":               shouldParseOperands,
-		"should run when cmd parsed":          shouldRun,
-		"should run subcommands":              shouldRunSubcmd,
+		"should err when no command provided":          shouldErrNilCmd,
+		"should err when no args provided":             shouldErrNoArgs,
+		"should err when help arg provided":            shouldErrHelpArg,
+		"should err when args invalid":                 shouldErrArgsInvalid,
+		"should err when arg bind fails":               shouldErrArgBind,
+		"should err when syntax unsupported":           shouldErrSyntax,
+		"should err when run with ctx err":             shouldErrCtxCanceled,
+		"should parse operands":                        shouldParseOperands,
+		"should parse subcmd operands":                 shouldParseSubcmdOperands,
+		"should run when cmd parsed":                   shouldRun,
+		"should run subcommands":                       shouldRunSubcmd,
+		"should not bind when subcmd invalid":          shouldNotBindInvalidSubcmd,
+		"should restore when bind fails":               shouldRestoreFailedBind,
+		"should run shared tree concurrently":          shouldRunConcurrently,
+		"should run aliased subcommand":                shouldRunAlias,
+		"should run unique prefix subcommand":          shouldRunPrefix,
+		"should err when prefix ambiguous":             shouldErrAmbiguousPrefix,
+		"should show aliases in help":                  shouldHelpAliases,
+		"should err when run errors":                   shouldErrRunE,
+		"should run hooks in tree order":               shouldRunHooks,
+		"should parse persistent args":                 shouldParsePersistentArg,
+		"should wrap run with middleware":              shouldRunMiddleware,
+		"should run by policy":                         shouldRunPolicy,
+		"should validate operands":                     shouldValidateOperands,
+		"should run lazy subcommand":                   shouldRunLazySubcmd,
+		"should run chained siblings in order":         shouldRunChainSequential,
+		"should run chained siblings in parallel":      shouldRunChainParallel,
+		"should time out hung command":                 shouldTimeoutHungCmd,
+		"should run within timeout":                    shouldRunWithinTimeout,
+		"should override timeout with flag":            shouldOverrideTimeoutWithFlag,
+		"should prefer command timeout option":         shouldPreferCmdTimeoutOpt,
+		"should list timeout flag in help":             shouldHelpTimeoutFlag,
+		"should err with usage exit code":              shouldErrUsageExitCode,
+		"should exit ok for help":                      shouldExitOKForHelp,
+		"should exit failure for run error":            shouldExitFailureForRunErr,
+		"should use exit error code":                   shouldUseExitErrCode,
+		"should pass io to command":                    shouldPassIOToCmd,
+		"should default io streams":                    shouldDefaultIOStreams,
+		"should run with args":                         shouldRunWithArgs,
+		"should override syntax":                       shouldOverrideSyntax,
+		"should set output":                            shouldSetOutput,
+		"should use program name in help":              shouldHelpProgramName,
+		"should dispatch multi-call program name":      shouldDispatchMultiCall,
+		"should parse without running":                 shouldParseWithoutRunning,
+		"should parse alias value source":              shouldParseAliasSource,
+		"should err executing foreign parse result":    shouldErrExecuteForeignResult,
+		"should explain without running":               shouldExplainWithoutRunning,
+		"should explain with flag":                     shouldExplainWithFlag,
+		"should trace parsing rules":                   shouldTraceRules,
+		"should trace from environment":                shouldTraceFromEnv,
+		"should err when version arg provided":         shouldErrVersionArg,
+		"should err with build info version":           shouldErrBuildInfoVersion,
+		"should not add version arg by default":        shouldNotAddVersionArg,
+		"should prefer command version option":         shouldPreferCmdVersionOpt,
+		"should write profiles":                        shouldWriteProfiles,
+		"should serve profiles":                        shouldServeProfiles,
+		"should hide profiling flags":                  shouldHideProfilingFlags,
+		"should prefix match own subcommands":          shouldPrefixMatchOwnSubcmds,
+		"should not prefix match operands":             shouldNotPrefixMatchOperands,
+		"should not prefix match option value":         shouldNotPrefixMatchOptValue,
+		"should not prefix match after terminator":     shouldNotPrefixMatchTerminated,
+		"should not prefix match siblings":             shouldNotPrefixMatchSiblings,
+		"should parse arg shared by siblings":          shouldParseSharedSiblingArg,
+		"should err repeated persistent arg":           shouldErrRepeatedPersistentArg,
+		"should not build lazy subcommand for help":    shouldNotBuildLazySubcmdForHelp,
+		"should restore snapshot binder":               shouldRestoreSnapshotBinder,
+		"should err explaining invalid value":          shouldErrExplainInvalidValue,
+		"should err executing result twice":            shouldErrExecutingResultTwice,
+		"should pass env to command":                   shouldPassEnvToCmd,
+		"should parse many options":                    shouldParseManyOptions,
+		"should start parallel siblings after failure": shouldStartParallelSiblingsAfterFailure,
+		"help only accepted short names":               shouldHelpOnlyAcceptedShortNames,
 	}
 
 	for name, test := range testCases {
@@ -172,6 +259,30 @@ func shouldParseOperands(t *testing.T, name string, syn clapr.ArgSyntax) {
 	}
 }
 
+func shouldParseSubcmdOperands(t *testing.T, name string, syn clapr.ArgSyntax) {
+	os.Args = []string{"test", "sub", "--", "foo", "-b"}
+	var got []string
+	cmd := &clapr.Command{}
+	cmd.AddSubcommand(&clapr.Command{
+		Name:     "sub",
+		Operands: clapr.ExactOperands(2),
+		Run: func(_ context.Context, operands []string) {
+			got = operands
+		},
+	})
+	runner := clapr.NewRunner(cmd, syn)
+
+	if err := runner.Run(context.Background()); err != nil {
+		t.Fail()
+		t.Logf("%s: errored: %v", name, err)
+	}
+
+	if strings.Join(got, " ") != "foo -b" {
+		t.Fail()
+		t.Errorf("%s: syntax: %s, wrong operands: %q", name, getSynName(syn), got)
+	}
+}
+
 func shouldRun(t *testing.T, name string, syn clapr.ArgSyntax) {
 	os.Args = []string{"test"}
 	ran := false
@@ -221,6 +332,1580 @@ func shouldRunSubcmd(t *testing.T, name string, syn clapr.ArgSyntax) {
 	}
 }
 
+func shouldNotBindInvalidSubcmd(t *testing.T, name string, syn clapr.ArgSyntax) {
+	os.Args = []string{"test", "--name=foo", "sub", "--bar"}
+
+	if syn == clapr.POSIX {
+		os.Args = []string{"test", "-n", "foo", "sub", "-b"}
+	}
+
+	val := ""
+	cmd := &clapr.Command{
+		Args: []*clapr.Arg{{Binder: clapr.NewStringArgBinder(&val), Name: "name", ShortName: 'n'}},
+	}
+	cmd.AddSubcommand(&clapr.Command{Name: "sub"})
+	runner := clapr.NewRunner(cmd, syn)
+
+	if err := runner.Run(context.Background()); err == nil {
+		t.Fail()
+		t.Logf("%s: syntax: %s, did not error", name, getSynName(syn))
+	}
+
+	if val != "" {
+		t.Fail()
+		t.Errorf("%s: syntax: %s, bound value: %s", name, getSynName(syn), val)
+	}
+}
+
+func shouldRestoreFailedBind(t *testing.T, name string, syn clapr.ArgSyntax) {
+	os.Args = []string{"test", "--num=5", "sub", "--count=foo"}
+
+	if syn == clapr.POSIX {
+		os.Args = []string{"test", "-n", "5", "sub", "-c", "foo"}
+	}
+
+	num := 1
+	count := 2
+	cmd := &clapr.Command{
+		Args: []*clapr.Arg{{Binder: clapr.NewIntArgBinder(&num), Name: "num", ShortName: 'n'}},
+	}
+	cmd.AddSubcommand(&clapr.Command{
+		Args: []*clapr.Arg{{Binder: clapr.NewIntArgBinder(&count), Name: "count", ShortName: 'c'}},
+		Name: "sub",
+	})
+	runner := clapr.NewRunner(cmd, syn)
+
+	if err := runner.Run(context.Background()); err == nil {
+		t.Fail()
+		t.Logf("%s: syntax: %s, did not error", name, getSynName(syn))
+	}
+
+	if num != 1 || count != 2 {
+		t.Fail()
+		t.Errorf("%s: syntax: %s, values not restored: num %d, count %d", name, getSynName(syn), num, count)
+	}
+}
+
+func shouldRestoreSnapshotBinder(t *testing.T, name string, syn clapr.ArgSyntax) {
+	os.Args = []string{"test", "--name=foo", "--count=bar"}
+
+	if syn == clapr.POSIX {
+		os.Args = []string{"test", "-n", "foo", "-c", "bar"}
+	}
+
+	val := "default"
+	var binder clapr.SnapshotBinder = &testSnapshotBinder{val: &val}
+	cmd := &clapr.Command{
+		Args: []*clapr.Arg{
+			{Binder: binder, Name: "name", ShortName: 'n'},
+			{Binder: clapr.NewIntArgBinder(new(int)), Name: "count", ShortName: 'c'},
+		},
+	}
+	runner := clapr.NewRunner(cmd, syn)
+
+	if err := runner.Run(context.Background()); err == nil || val != "default" {
+		t.Fail()
+		t.Logf("%s: syntax: %s, val: %s, err: %v", name, getSynName(syn), val, err)
+	}
+}
+
+func shouldRunConcurrently(t *testing.T, name string, syn clapr.ArgSyntax) {
+	const count = 8
+	results := make(chan []string, count)
+	cmd := &clapr.Command{
+		Args: []*clapr.Arg{{Name: "foo", ShortName: 'f'}},
+	}
+	cmd.AddSubcommand(&clapr.Command{
+		Args: []*clapr.Arg{{Name: "foo", ShortName: 'f'}},
+		Name: "bar",
+		Run: func(_ context.Context, operands []string) {
+			results <- operands
+		},
+	})
+	runners := make([]clapr.Runner, count)
+
+	for i := range runners {
+		os.Args = []string{"test", "-f", "val", "bar", "-f", "val", fmt.Sprintf("op%d", i)}
+		runners[i] = clapr.NewRunner(cmd, syn)
+	}
+
+	var wg sync.WaitGroup
+
+	for _, runner := range runners {
+		wg.Add(1)
+
+		go func(runner clapr.Runner) {
+			defer wg.Done()
+
+			if err := runner.Run(context.Background()); err != nil {
+				t.Errorf("%s: syntax: %s, errored: %v", name, getSynName(syn), err)
+			}
+		}(runner)
+	}
+
+	wg.Wait()
+	close(results)
+	seen := map[string]bool{}
+
+	for operands := range results {
+		if len(operands) != 1 {
+			t.Fail()
+			t.Logf("%s: syntax: %s, unexpected operands: %v", name, getSynName(syn), operands)
+
+			continue
+		}
+
+		seen[operands[0]] = true
+	}
+
+	if len(seen) != count || len(cmd.Args) != 1 || cmd.Args[0].Required {
+		t.Fail()
+		t.Logf("%s: syntax: %s, runs interfered: %v", name, getSynName(syn), seen)
+	}
+}
+
+func shouldRunAlias(t *testing.T, name string, syn clapr.ArgSyntax) {
+	os.Args = []string{"test", "st"}
+	ran := false
+	cmd := &clapr.Command{}
+	cmd.AddSubcommand(&clapr.Command{
+		Aliases: []string{"st"},
+		Name:    "status",
+		Run: func(context.Context, []string) {
+			ran = true
+		},
+	})
+	runner := clapr.NewRunner(cmd, syn)
+
+	if err := runner.Run(context.Background()); err != nil {
+		t.Fail()
+		t.Logf("%s: errored: %v", name, err)
+	}
+
+	if !ran {
+		t.Fail()
+		t.Errorf("%s: syntax: %s, did not run", name, getSynName(syn))
+	}
+}
+
+func shouldRunPrefix(t *testing.T, name string, syn clapr.ArgSyntax) {
+	os.Args = []string{"test", "stat"}
+	ran := false
+	cmd := &clapr.Command{}
+	cmd.AddSubcommand(&clapr.Command{
+		Name: "status",
+		Run: func(context.Context, []string) {
+			ran = true
+		},
+	}, &clapr.Command{Name: "stash"})
+	runner := clapr.NewRunner(cmd, syn, clapr.WithPrefixMatching())
+
+	if err := runner.Run(context.Background()); err != nil {
+		t.Fail()
+		t.Logf("%s: errored: %v", name, err)
+	}
+
+	if !ran {
+		t.Fail()
+		t.Errorf("%s: syntax: %s, did not run", name, getSynName(syn))
+	}
+}
+
+func shouldErrAmbiguousPrefix(t *testing.T, name string, syn clapr.ArgSyntax) {
+	os.Args = []string{"test", "st"}
+	cmd := &clapr.Command{}
+	cmd.AddSubcommand(&clapr.Command{Name: "status"}, &clapr.Command{Name: "stash"})
+	runner := clapr.NewRunner(cmd, syn, clapr.WithPrefixMatching())
+	err := runner.Run(context.Background())
+
+	if err == nil || !strings.Contains(err.Error(), "stash, status") {
+		t.Fail()
+		t.Logf("%s: syntax: %s, did not list candidates: %v", name, getSynName(syn), err)
+	}
+}
+
+func shouldPrefixMatchOwnSubcmds(t *testing.T, name string, syn clapr.ArgSyntax) {
+	os.Args = []string{"test", "status", "s"}
+	ran := false
+	status := &clapr.Command{Name: "status"}
+	status.AddSubcommand(&clapr.Command{
+		Name: "show",
+		Run: func(context.Context, []string) {
+			ran = true
+		},
+	})
+	cmd := &clapr.Command{}
+	cmd.AddSubcommand(status, &clapr.Command{Name: "stash"})
+	runner := clapr.NewRunner(cmd, syn, clapr.WithPrefixMatching())
+
+	if err := runner.Run(context.Background()); err != nil || !ran {
+		t.Fail()
+		t.Logf("%s: syntax: %s, ran: %t, err: %v", name, getSynName(syn), ran, err)
+	}
+}
+
+func shouldNotPrefixMatchSiblings(t *testing.T, name string, syn clapr.ArgSyntax) {
+	os.Args = []string{"test", "status", "s"}
+	cmd := &clapr.Command{}
+	cmd.AddSubcommand(&clapr.Command{Name: "status"}, &clapr.Command{Name: "stash"})
+	runner := clapr.NewRunner(cmd, syn, clapr.WithPrefixMatching())
+	err := runner.Run(context.Background())
+
+	if err == nil || strings.Contains(err.Error(), "ambiguous") || !strings.Contains(err.Error(), "invalid option: s") {
+		t.Fail()
+		t.Logf("%s: syntax: %s, unexpected error: %v", name, getSynName(syn), err)
+	}
+}
+
+func shouldNotPrefixMatchOperands(t *testing.T, name string, syn clapr.ArgSyntax) {
+	os.Args = []string{"test", "status", "--name=foo", "st"}
+
+	if syn == clapr.POSIX {
+		os.Args = []string{"test", "status", "-n", "foo", "st"}
+	}
+
+	var got []string
+	val := ""
+	cmd := &clapr.Command{}
+	cmd.AddSubcommand(&clapr.Command{
+		Args: []*clapr.Arg{{Binder: clapr.NewStringArgBinder(&val), Name: "name", ShortName: 'n'}},
+		Name: "status",
+		Run: func(_ context.Context, operands []string) {
+			got = operands
+		},
+	}, &clapr.Command{Name: "stash"})
+	runner := clapr.NewRunner(cmd, syn, clapr.WithPrefixMatching())
+
+	if err := runner.Run(context.Background()); err != nil || val != "foo" || strings.Join(got, " ") != "st" {
+		t.Fail()
+		t.Logf("%s: syntax: %s, val: %s, operands: %q, err: %v", name, getSynName(syn), val, got, err)
+	}
+}
+
+func shouldNotPrefixMatchOptValue(t *testing.T, name string, syn clapr.ArgSyntax) {
+	os.Args = []string{"test", "status", "--name", "st"}
+
+	if syn == clapr.POSIX {
+		os.Args = []string{"test", "status", "-n", "st"}
+	}
+
+	val := ""
+	cmd := &clapr.Command{}
+	cmd.AddSubcommand(&clapr.Command{
+		Args: []*clapr.Arg{{Binder: clapr.NewStringArgBinder(&val), Name: "name", Required: true, ShortName: 'n'}},
+		Name: "status",
+	}, &clapr.Command{Name: "stash"})
+	runner := clapr.NewRunner(cmd, syn, clapr.WithPrefixMatching())
+
+	if err := runner.Run(context.Background()); err != nil || val != "st" {
+		t.Fail()
+		t.Logf("%s: syntax: %s, val: %s, err: %v", name, getSynName(syn), val, err)
+	}
+}
+
+func shouldNotPrefixMatchTerminated(t *testing.T, name string, syn clapr.ArgSyntax) {
+	os.Args = []string{"test", "status", "--", "st"}
+	var got []string
+	cmd := &clapr.Command{}
+	cmd.AddSubcommand(&clapr.Command{
+		Name: "status",
+		Run: func(_ context.Context, operands []string) {
+			got = operands
+		},
+	}, &clapr.Command{Name: "stash"})
+	runner := clapr.NewRunner(cmd, syn, clapr.WithPrefixMatching())
+
+	if err := runner.Run(context.Background()); err != nil || strings.Join(got, " ") != "st" {
+		t.Fail()
+		t.Logf("%s: syntax: %s, operands: %q, err: %v", name, getSynName(syn), got, err)
+	}
+}
+
+func shouldHelpAliases(t *testing.T, name string, syn clapr.ArgSyntax) {
+	os.Args = []string{"test", "-h"}
+	cmd := &clapr.Command{Name: "test"}
+	cmd.AddSubcommand(&clapr.Command{Aliases: []string{"st"}, Name: "status"})
+	runner := clapr.NewRunner(cmd, syn)
+	err := runner.Run(context.Background())
+
+	if err == nil || !strings.Contains(err.Error(), "[status, st]") {
+		t.Fail()
+		t.Logf("%s: syntax: %s, aliases not in help: %v", name, getSynName(syn), err)
+	}
+}
+
+func shouldErrRunE(t *testing.T, name string, syn clapr.ArgSyntax) {
+	os.Args = []string{"test", "foo", "bar"}
+	runerr := fmt.Errorf("should error")
+	cmd := &clapr.Command{}
+	foo := &clapr.Command{
+		Name: "foo",
+		RunE: func(context.Context, []string) error {
+			return runerr
+		},
+	}
+	foo.AddSubcommand(&clapr.Command{
+		Name: "bar",
+		Run: func(context.Context, []string) {
+			t.Fail()
+			t.Logf("%s: syntax: %s, incorrectly called command run", name, getSynName(syn))
+		},
+	})
+	cmd.AddSubcommand(foo)
+	runner := clapr.NewRunner(cmd, syn)
+
+	if err := runner.Run(context.Background()); !errors.Is(err, runerr) {
+		t.Fail()
+		t.Logf("%s: syntax: %s, unexpected error: %v", name, getSynName(syn), err)
+	}
+}
+
+func shouldRunHooks(t *testing.T, name string, syn clapr.ArgSyntax) {
+	os.Args = []string{"test", "foo"}
+	var ran []string
+	hook := func(step string, err error) func(context.Context, []string) error {
+		return func(context.Context, []string) error {
+			ran = append(ran, step)
+
+			return err
+		}
+	}
+	runerr := fmt.Errorf("should error")
+	cmd := &clapr.Command{
+		PersistentPostRun: hook("root persistent post", nil),
+		PersistentPreRun:  hook("root persistent pre", nil),
+		RunE:              hook("root run", nil),
+	}
+	cmd.AddSubcommand(&clapr.Command{
+		Name:    "foo",
+		PostRun: hook("foo post", nil),
+		PreRun:  hook("foo pre", nil),
+		RunE:    hook("foo run", runerr),
+	})
+	runner := clapr.NewRunner(cmd, syn)
+	expect := []string{
+		"root persistent pre",
+		"root run",
+		"foo pre",
+		"foo run",
+		"foo post",
+		"root persistent post",
+	}
+
+	if err := runner.Run(context.Background()); !errors.Is(err, runerr) {
+		t.Fail()
+		t.Logf("%s: syntax: %s, unexpected error: %v", name, getSynName(syn), err)
+	}
+
+	if !reflect.DeepEqual(ran, expect) {
+		t.Fail()
+		t.Errorf("%s: syntax: %s, expected: %v got: %v", name, getSynName(syn), expect, ran)
+	}
+}
+
+func shouldParsePersistentArg(t *testing.T, name string, syn clapr.ArgSyntax) {
+	os.Args = []string{"test", "foo", "bar", "--verbose"}
+
+	if syn == clapr.POSIX {
+		os.Args = []string{"test", "foo", "bar", "-v"}
+	}
+
+	val := false
+	cmd := &clapr.Command{
+		Args: []*clapr.Arg{{Binder: clapr.NewBoolArgBinder(&val), Name: "verbose", Persistent: true, ShortName: 'v'}},
+	}
+	foo := &clapr.Command{Name: "foo"}
+	foo.AddSubcommand(&clapr.Command{Name: "bar"})
+	cmd.AddSubcommand(foo)
+	runner := clapr.NewRunner(cmd, syn)
+
+	if err := runner.Run(context.Background()); err != nil {
+		t.Fail()
+		t.Logf("%s: syntax: %s, errored: %v", name, getSynName(syn), err)
+	}
+
+	if !val {
+		t.Fail()
+		t.Errorf("%s: syntax: %s, did not bind", name, getSynName(syn))
+	}
+}
+
+func shouldParseSharedSiblingArg(t *testing.T, name string, syn clapr.ArgSyntax) {
+	os.Args = []string{"test", "build", "--verbose", "test", "--verbose"}
+
+	if syn == clapr.POSIX {
+		os.Args = []string{"test", "build", "-v", "test", "-v"}
+	}
+
+	val := false
+	verbose := &clapr.Arg{Binder: clapr.NewBoolArgBinder(&val), Name: "verbose", ShortName: 'v'}
+	cmd := &clapr.Command{}
+	cmd.AddSubcommand(
+		&clapr.Command{Args: []*clapr.Arg{verbose}, Name: "build"},
+		&clapr.Command{Args: []*clapr.Arg{verbose}, Name: "test"},
+	)
+	runner := clapr.NewRunner(cmd, syn)
+
+	if err := runner.Run(context.Background()); err != nil || !val {
+		t.Fail()
+		t.Logf("%s: syntax: %s, val: %t, err: %v", name, getSynName(syn), val, err)
+	}
+}
+
+func shouldErrRepeatedPersistentArg(t *testing.T, name string, syn clapr.ArgSyntax) {
+	os.Args = []string{"test", "--verbose", "foo", "--verbose"}
+
+	if syn == clapr.POSIX {
+		os.Args = []string{"test", "-v", "foo", "-v"}
+	}
+
+	cmd := &clapr.Command{
+		Args: []*clapr.Arg{{Binder: clapr.NewBoolArgBinder(new(bool)), Name: "verbose", Persistent: true, ShortName: 'v'}},
+	}
+	cmd.AddSubcommand(&clapr.Command{Name: "foo"})
+	runner := clapr.NewRunner(cmd, syn)
+	err := runner.Run(context.Background())
+
+	if err == nil || !strings.Contains(err.Error(), "non-repeatable option") {
+		t.Fail()
+		t.Logf("%s: syntax: %s, unexpected error: %v", name, getSynName(syn), err)
+	}
+}
+
+func shouldRunMiddleware(t *testing.T, name string, syn clapr.ArgSyntax) {
+	os.Args = []string{"test"}
+	var ran []string
+	mw := func(step string) clapr.Middleware {
+		return func(next clapr.RunFunc) clapr.RunFunc {
+			return func(ctx context.Context, operands []string) error {
+				ran = append(ran, step)
+
+				return next(ctx, operands)
+			}
+		}
+	}
+	runner := clapr.NewRunner(&clapr.Command{
+		Middleware: []clapr.Middleware{mw("command")},
+		Run: func(context.Context, []string) {
+			ran = append(ran, "run")
+		},
+	}, syn, clapr.WithMiddleware(mw("runner first"), mw("runner second")))
+	expect := []string{"runner first", "runner second", "command", "run"}
+
+	if err := runner.Run(context.Background()); err != nil {
+		t.Fail()
+		t.Logf("%s: syntax: %s, errored: %v", name, getSynName(syn), err)
+	}
+
+	if !reflect.DeepEqual(ran, expect) {
+		t.Fail()
+		t.Errorf("%s: syntax: %s, expected: %v got: %v", name, getSynName(syn), expect, ran)
+	}
+}
+
+func shouldRunPolicy(t *testing.T, name string, syn clapr.ArgSyntax) {
+	tests := map[clapr.RunPolicy][]string{
+		clapr.RunChain:    {"root", "foo", "bar"},
+		clapr.RunLeaf:     {"bar"},
+		clapr.RunExplicit: {"root", "bar"},
+	}
+
+	for policy, expect := range tests {
+		os.Args = []string{"test", "foo", "bar"}
+		var ran []string
+		run := func(step string) func(context.Context, []string) {
+			return func(context.Context, []string) {
+				ran = append(ran, step)
+			}
+		}
+		cmd := &clapr.Command{Run: run("root")}
+		foo := &clapr.Command{Name: "foo", Passthrough: true, Run: run("foo")}
+		foo.AddSubcommand(&clapr.Command{Name: "bar", Run: run("bar")})
+		cmd.AddSubcommand(foo)
+		runner := clapr.NewRunner(cmd, syn, clapr.WithRunPolicy(policy))
+
+		if err := runner.Run(context.Background()); err != nil {
+			t.Fail()
+			t.Logf("%s: syntax: %s, errored: %v", name, getSynName(syn), err)
+		}
+
+		if !reflect.DeepEqual(ran, expect) {
+			t.Fail()
+			t.Errorf("%s: syntax: %s, policy: %d, expected: %v got: %v", name, getSynName(syn), policy, expect, ran)
+		}
+	}
+}
+
+func shouldValidateOperands(t *testing.T, name string, syn clapr.ArgSyntax) {
+	tests := []struct {
+		validator clapr.OperandValidator
+		valid     bool
+	}{
+		{clapr.NoOperands, false},
+		{clapr.ExactOperands(2), true},
+		{clapr.ExactOperands(1), false},
+		{clapr.MinOperands(2), true},
+		{clapr.MinOperands(3), false},
+		{clapr.RangeOperands(1, 2), true},
+		{clapr.RangeOperands(3, 4), false},
+	}
+
+	for i, test := range tests {
+		os.Args = []string{"test", "--", "foo", "bar"}
+		ran := false
+		runner := clapr.NewRunner(&clapr.Command{
+			Operands: test.validator,
+			Run: func(context.Context, []string) {
+				ran = true
+			},
+		}, syn)
+		err := runner.Run(context.Background())
+		var helpErr *clapr.ErrHelp
+
+		if test.valid && (err != nil || !ran) {
+			t.Fail()
+			t.Logf("%s: syntax: %s, test %d errored: %v", name, getSynName(syn), i, err)
+		}
+
+		if !test.valid && (ran || !errors.As(err, &helpErr)) {
+			t.Fail()
+			t.Logf("%s: syntax: %s, test %d did not error with help", name, getSynName(syn), i)
+		}
+	}
+}
+
+func shouldRunLazySubcmd(t *testing.T, name string, syn clapr.ArgSyntax) {
+	os.Args = []string{"test", "foo"}
+	var built []string
+	ran := false
+	cmd := &clapr.Command{}
+	cmd.AddLazySubcommand("foo", "runs foo", func() *clapr.Command {
+		built = append(built, "foo")
+
+		return &clapr.Command{
+			Run: func(context.Context, []string) {
+				ran = true
+			},
+		}
+	})
+	cmd.AddLazySubcommand("bar", "runs bar", func() *clapr.Command {
+		built = append(built, "bar")
+
+		return &clapr.Command{}
+	})
+	runner := clapr.NewRunner(cmd, syn)
+
+	if err := runner.Run(context.Background()); err != nil {
+		t.Fail()
+		t.Logf("%s: syntax: %s, errored: %v", name, getSynName(syn), err)
+	}
+
+	if !ran || !reflect.DeepEqual(built, []string{"foo"}) {
+		t.Fail()
+		t.Errorf("%s: syntax: %s, ran: %t, built: %v", name, getSynName(syn), ran, built)
+	}
+
+	if path := cmd.Find("foo").Path(); path != "foo" || len(built) != 1 {
+		t.Fail()
+		t.Errorf("%s: syntax: %s, path: %s, built: %v", name, getSynName(syn), path, built)
+	}
+}
+
+func shouldNotBuildLazySubcmdForHelp(t *testing.T, name string, syn clapr.ArgSyntax) {
+	for _, args := range [][]string{{"test", "-h"}, {"test", "--bogus"}} {
+		os.Args = args
+		built := false
+		cmd := &clapr.Command{Name: "test"}
+		cmd.AddLazySubcommand("foo", "runs foo", func() *clapr.Command {
+			built = true
+
+			return &clapr.Command{}
+		})
+		runner := clapr.NewRunner(cmd, syn)
+		err := runner.Run(context.Background())
+		var helpErr *clapr.ErrHelp
+
+		if !errors.As(err, &helpErr) || !strings.Contains(helpErr.Help, "[foo]  runs foo") {
+			t.Fail()
+			t.Logf("%s: syntax: %s, args: %v, lazy subcommand not in help: %v", name, getSynName(syn), args, err)
+		}
+
+		if built {
+			t.Fail()
+			t.Errorf("%s: syntax: %s, args: %v, constructed lazy subcommand", name, getSynName(syn), args)
+		}
+	}
+}
+
+func shouldRunChainSequential(t *testing.T, name string, syn clapr.ArgSyntax) {
+	os.Args = []string{"test", "build", "lint", "test"}
+	var ran []string
+	linterr := fmt.Errorf("should error")
+	cmd := &clapr.Command{}
+	cmd.AddSubcommand(getChainCmd("build", &ran, nil), getChainCmd("lint", &ran, linterr), getChainCmd("test", &ran, nil))
+	runner := clapr.NewRunner(cmd, syn, clapr.WithChaining(clapr.ChainSequential))
+	err := runner.Run(context.Background())
+	var chainErr *clapr.ChainError
+
+	if !errors.As(err, &chainErr) || len(chainErr.Errors) != 1 || chainErr.Errors[0].Command.Name != "lint" {
+		t.Fail()
+		t.Logf("%s: syntax: %s, unexpected error: %v", name, getSynName(syn), err)
+	}
+
+	if !errors.Is(err, linterr) || !reflect.DeepEqual(ran, []string{"build", "lint"}) {
+		t.Fail()
+		t.Errorf("%s: syntax: %s, ran: %v, err: %v", name, getSynName(syn), ran, err)
+	}
+}
+
+func shouldRunChainParallel(t *testing.T, name string, syn clapr.ArgSyntax) {
+	os.Args = []string{"test", "build", "lint", "test"}
+	linterr := fmt.Errorf("should error")
+	wait := func(name string) *clapr.Command {
+		return &clapr.Command{
+			Name: name,
+			RunE: func(ctx context.Context, _ []string) error {
+				select {
+				case <-ctx.Done():
+					return ctx.Err()
+				case <-time.After(5 * time.Second):
+					return nil
+				}
+			},
+		}
+	}
+	var ran []string
+	cmd := &clapr.Command{}
+	cmd.AddSubcommand(wait("build"), getChainCmd("lint", &ran, linterr), wait("test"))
+	runner := clapr.NewRunner(cmd, syn, clapr.WithChaining(clapr.ChainParallel))
+	start := time.Now()
+	err := runner.Run(context.Background())
+	var chainErr *clapr.ChainError
+
+	if !errors.As(err, &chainErr) || len(chainErr.Errors) != 1 || !errors.Is(err, linterr) {
+		t.Fail()
+		t.Logf("%s: syntax: %s, unexpected error: %v", name, getSynName(syn), err)
+	}
+
+	if time.Since(start) >= 5*time.Second {
+		t.Fail()
+		t.Errorf("%s: syntax: %s, siblings not canceled", name, getSynName(syn))
+	}
+}
+
+func shouldTimeoutHungCmd(t *testing.T, name string, syn clapr.ArgSyntax) {
+	os.Args = []string{"test", "sleep"}
+	returned := false
+	cmd := &clapr.Command{Name: "test"}
+	cmd.AddSubcommand(&clapr.Command{
+		Name:    "sleep",
+		Timeout: 20 * time.Millisecond,
+		Run: func(ctx context.Context, _ []string) {
+			<-ctx.Done()
+			returned = true
+		},
+	})
+	runner := clapr.NewRunner(cmd, syn)
+	err := runner.Run(context.Background())
+
+	if !returned {
+		t.Fail()
+		t.Logf("%s: syntax: %s, run returned before command", name, getSynName(syn))
+	}
+
+	if err == nil || err.Error() != "command test sleep timed out after 20ms: context deadline exceeded" {
+		t.Fail()
+		t.Logf("%s: syntax: %s, unexpected error: %v", name, getSynName(syn), err)
+	}
+
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fail()
+		t.Errorf("%s: syntax: %s, error is not a deadline error", name, getSynName(syn))
+	}
+}
+
+func shouldRunWithinTimeout(t *testing.T, name string, syn clapr.ArgSyntax) {
+	os.Args = []string{"test", "sleep"}
+	hasDeadline := false
+	cmd := &clapr.Command{}
+	cmd.AddSubcommand(&clapr.Command{
+		Name:    "sleep",
+		Timeout: time.Minute,
+		Run: func(ctx context.Context, _ []string) {
+			_, hasDeadline = ctx.Deadline()
+		},
+	})
+	runner := clapr.NewRunner(cmd, syn)
+
+	if err := runner.Run(context.Background()); err != nil || !hasDeadline {
+		t.Fail()
+		t.Logf("%s: syntax: %s, deadline: %t, err: %v", name, getSynName(syn), hasDeadline, err)
+	}
+}
+
+func shouldOverrideTimeoutWithFlag(t *testing.T, name string, syn clapr.ArgSyntax) {
+	if syn == clapr.POSIX {
+		return
+	}
+
+	os.Args = []string{"test", "sleep", "--timeout=20ms"}
+	cmd := &clapr.Command{}
+	cmd.AddSubcommand(&clapr.Command{
+		Name:    "sleep",
+		Timeout: time.Minute,
+		RunE: func(ctx context.Context, _ []string) error {
+			<-ctx.Done()
+
+			return ctx.Err()
+		},
+	})
+	runner := clapr.NewRunner(cmd, syn, clapr.WithTimeoutFlag())
+
+	if err := runner.Run(context.Background()); err == nil || !strings.Contains(err.Error(), "timed out after 20ms") {
+		t.Fail()
+		t.Logf("%s: syntax: %s, unexpected error: %v", name, getSynName(syn), err)
+	}
+}
+
+func shouldPreferCmdTimeoutOpt(t *testing.T, name string, syn clapr.ArgSyntax) {
+	if syn == clapr.POSIX {
+		return
+	}
+
+	os.Args = []string{"test", "--timeout=5"}
+	timeout := 0
+	cmd := &clapr.Command{
+		Args: []*clapr.Arg{{Binder: clapr.NewIntArgBinder(&timeout), Name: "timeout"}},
+	}
+	runner := clapr.NewRunner(cmd, syn, clapr.WithTimeoutFlag())
+
+	if err := runner.Run(context.Background()); err != nil || timeout != 5 {
+		t.Fail()
+		t.Logf("%s: syntax: %s, timeout: %d, err: %v", name, getSynName(syn), timeout, err)
+	}
+}
+
+func shouldHelpTimeoutFlag(t *testing.T, name string, syn clapr.ArgSyntax) {
+	if syn == clapr.POSIX {
+		return
+	}
+
+	os.Args = []string{"test", "sub", "-h"}
+	cmd := &clapr.Command{}
+	cmd.AddSubcommand(&clapr.Command{Name: "sub"})
+	runner := clapr.NewRunner(cmd, syn, clapr.WithTimeoutFlag())
+	err := runner.Run(context.Background())
+
+	if err == nil || !strings.Contains(err.Error(), "Global Options:") || !strings.Contains(err.Error(), "timeout") {
+		t.Fail()
+		t.Logf("%s: syntax: %s, timeout flag not in help: %v", name, getSynName(syn), err)
+	}
+}
+
+func shouldErrUsageExitCode(t *testing.T, name string, syn clapr.ArgSyntax) {
+	os.Args = []string{"test", "--bogus"}
+	runner := clapr.NewRunner(&clapr.Command{}, syn)
+	err := runner.Run(context.Background())
+	var exitErr *clapr.ExitError
+	var helpErr *clapr.ErrHelp
+
+	if !errors.As(err, &exitErr) || clapr.ExitCode(err) != clapr.ExitUsage {
+		t.Fail()
+		t.Logf("%s: syntax: %s, unexpected error: %v", name, getSynName(syn), err)
+	}
+
+	if !errors.As(err, &helpErr) {
+		t.Fail()
+		t.Errorf("%s: syntax: %s, help not included", name, getSynName(syn))
+	}
+}
+
+func shouldExitOKForHelp(t *testing.T, name string, syn clapr.ArgSyntax) {
+	os.Args = []string{"test", "-h"}
+	runner := clapr.NewRunner(&clapr.Command{}, syn)
+
+	if err := runner.Run(context.Background()); err == nil || clapr.ExitCode(err) != clapr.ExitOK {
+		t.Fail()
+		t.Logf("%s: syntax: %s, unexpected error: %v", name, getSynName(syn), err)
+	}
+}
+
+func shouldExitFailureForRunErr(t *testing.T, name string, syn clapr.ArgSyntax) {
+	os.Args = []string{"test"}
+	runner := clapr.NewRunner(&clapr.Command{
+		RunE: func(context.Context, []string) error {
+			return fmt.Errorf("should error")
+		},
+	}, syn)
+
+	if code := clapr.ExitCode(runner.Run(context.Background())); code != clapr.ExitFailure {
+		t.Fail()
+		t.Logf("%s: syntax: %s, unexpected exit code: %d", name, getSynName(syn), code)
+	}
+}
+
+func shouldUseExitErrCode(t *testing.T, name string, syn clapr.ArgSyntax) {
+	os.Args = []string{"test"}
+	runner := clapr.NewRunner(&clapr.Command{
+		RunE: func(context.Context, []string) error {
+			return &clapr.ExitError{Code: 3, Err: fmt.Errorf("should error")}
+		},
+	}, syn)
+
+	if code := clapr.ExitCode(runner.Run(context.Background())); code != 3 {
+		t.Fail()
+		t.Logf("%s: syntax: %s, unexpected exit code: %d", name, getSynName(syn), code)
+	}
+}
+
+func shouldPassIOToCmd(t *testing.T, name string, syn clapr.ArgSyntax) {
+	os.Args = []string{"test"}
+	var out strings.Builder
+	cmd := &clapr.Command{
+		RunE: func(ctx context.Context, _ []string) error {
+			streams := clapr.IO(ctx)
+			in, err := ioutil.ReadAll(streams.In)
+
+			if err != nil {
+				return err
+			}
+
+			_, err = fmt.Fprintf(streams.Out, "read %s", in)
+
+			return err
+		},
+	}
+	runner := clapr.NewRunner(cmd, syn, clapr.WithIO(clapr.IOStreams{In: strings.NewReader("foo"), Out: &out}))
+
+	if err := runner.Run(context.Background()); err != nil || out.String() != "read foo" {
+		t.Fail()
+		t.Logf("%s: syntax: %s, output: %q, err: %v", name, getSynName(syn), out.String(), err)
+	}
+}
+
+func shouldDefaultIOStreams(t *testing.T, name string, syn clapr.ArgSyntax) {
+	os.Args = []string{"test"}
+	var streams *clapr.IOStreams
+	cmd := &clapr.Command{
+		Run: func(ctx context.Context, _ []string) {
+			streams = clapr.IO(ctx)
+		},
+	}
+	runner := clapr.NewRunner(cmd, syn, clapr.WithIO(clapr.IOStreams{Out: ioutil.Discard}))
+
+	if err := runner.Run(context.Background()); err != nil || streams == nil {
+		t.Fail()
+		t.Logf("%s: syntax: %s, errored: %v", name, getSynName(syn), err)
+
+		return
+	}
+
+	if streams.In != os.Stdin || streams.Out != ioutil.Discard || streams.Err != os.Stderr {
+		t.Fail()
+		t.Errorf("%s: syntax: %s, unexpected streams: %v", name, getSynName(syn), streams)
+	}
+
+	if clapr.IO(context.Background()).Out != os.Stdout {
+		t.Fail()
+		t.Errorf("%s: syntax: %s, unexpected default output stream", name, getSynName(syn))
+	}
+}
+
+func shouldPassEnvToCmd(t *testing.T, name string, syn clapr.ArgSyntax) {
+	os.Args = []string{"test"}
+	var env []string
+	val := ""
+	cmd := &clapr.Command{
+		Run: func(ctx context.Context, _ []string) {
+			env = clapr.Env(ctx)
+			val = clapr.Getenv(ctx, "FOO")
+		},
+	}
+	runner := clapr.NewRunner(cmd, syn, clapr.WithEnv([]string{"FOO=bar", "BAZ=qux", "FOO=baz"}))
+
+	if err := runner.Run(context.Background()); err != nil || len(env) != 3 || val != "baz" {
+		t.Fail()
+		t.Logf("%s: syntax: %s, env: %v, val: %s, err: %v", name, getSynName(syn), env, val, err)
+	}
+
+	if clapr.Getenv(context.Background(), "PATH") != os.Getenv("PATH") {
+		t.Fail()
+		t.Errorf("%s: syntax: %s, did not fall back to process environment", name, getSynName(syn))
+	}
+}
+
+func shouldRunWithArgs(t *testing.T, name string, syn clapr.ArgSyntax) {
+	os.Args = []string{"test", "bogus"}
+	var got []string
+	cmd := &clapr.Command{}
+	cmd.AddSubcommand(&clapr.Command{
+		Name: "sub",
+		Run: func(_ context.Context, operands []string) {
+			got = operands
+		},
+	})
+	runner := clapr.NewRunner(cmd, syn, clapr.WithArgs("sub", "--", "foo"))
+
+	if err := runner.Run(context.Background()); err != nil || !reflect.DeepEqual(got, []string{"foo"}) {
+		t.Fail()
+		t.Logf("%s: syntax: %s, operands: %v, err: %v", name, getSynName(syn), got, err)
+	}
+}
+
+func shouldOverrideSyntax(t *testing.T, name string, syn clapr.ArgSyntax) {
+	val := ""
+	cmd := &clapr.Command{
+		Args: []*clapr.Arg{{Binder: clapr.NewStringArgBinder(&val), Name: "name", ShortName: 'n'}},
+	}
+	runner := clapr.NewRunner(cmd, syn, clapr.WithArgs("--name=foo"), clapr.WithSyntax(clapr.GNU))
+
+	if err := runner.Run(context.Background()); err != nil || val != "foo" {
+		t.Fail()
+		t.Logf("%s: syntax: %s, val: %s, err: %v", name, getSynName(syn), val, err)
+	}
+}
+
+func shouldSetOutput(t *testing.T, name string, syn clapr.ArgSyntax) {
+	var out strings.Builder
+	cmd := &clapr.Command{
+		Run: func(ctx context.Context, _ []string) {
+			fmt.Fprint(clapr.IO(ctx).Out, "out ")
+			fmt.Fprint(clapr.IO(ctx).Err, "err")
+		},
+	}
+	runner := clapr.NewRunner(cmd, syn, clapr.WithArgs(), clapr.WithOutput(&out))
+
+	if err := runner.Run(context.Background()); err != nil || out.String() != "out err" {
+		t.Fail()
+		t.Logf("%s: syntax: %s, output: %q, err: %v", name, getSynName(syn), out.String(), err)
+	}
+}
+
+func shouldHelpProgramName(t *testing.T, name string, syn clapr.ArgSyntax) {
+	cmd := &clapr.Command{Name: "tool"}
+	cmd.AddSubcommand(&clapr.Command{Name: "sub"})
+	runner := clapr.NewRunner(cmd, syn, clapr.WithArgs("sub", "-h"), clapr.WithProgramName("mytool"))
+	err := runner.Run(context.Background())
+
+	if err == nil || !strings.HasPrefix(err.Error(), "Usage:\n    mytool sub") {
+		t.Fail()
+		t.Logf("%s: syntax: %s, unexpected help: %v", name, getSynName(syn), err)
+	}
+}
+
+func shouldDispatchMultiCall(t *testing.T, name string, syn clapr.ArgSyntax) {
+	var got []string
+	cmd := &clapr.Command{Name: "box"}
+	cmd.AddSubcommand(&clapr.Command{
+		Name: "ls",
+		Run: func(_ context.Context, operands []string) {
+			got = operands
+		},
+	})
+	runner := clapr.NewRunner(cmd, syn, clapr.WithArgs("--", "foo"), clapr.WithProgramName("ls"))
+
+	if err := runner.Run(context.Background()); err != nil || !reflect.DeepEqual(got, []string{"foo"}) {
+		t.Fail()
+		t.Logf("%s: syntax: %s, operands: %v, err: %v", name, getSynName(syn), got, err)
+	}
+
+	runner = clapr.NewRunner(cmd, syn, clapr.WithArgs("-h"), clapr.WithProgramName("ls"))
+
+	if err := runner.Run(context.Background()); err == nil || err.Error() != "Usage:\n    ls" {
+		t.Fail()
+		t.Errorf("%s: syntax: %s, unexpected help: %v", name, getSynName(syn), err)
+	}
+}
+
+func shouldParseWithoutRunning(t *testing.T, name string, syn clapr.ArgSyntax) {
+	argv := []string{"sub", "--name=foo", "--quiet", "--", "bar"}
+
+	if syn == clapr.POSIX {
+		argv = []string{"sub", "-n", "foo", "-q", "--", "bar"}
+	}
+
+	val := ""
+	quiet := false
+	ran := false
+	sub := &clapr.Command{
+		Args: []*clapr.Arg{
+			{Binder: clapr.NewStringArgBinder(&val), Name: "name", ShortName: 'n'},
+			{Binder: clapr.NewBoolArgBinder(&quiet), Name: "quiet", ShortName: 'q'},
+		},
+		Name: "sub",
+		Run: func(context.Context, []string) {
+			ran = true
+		},
+	}
+	cmd := &clapr.Command{Name: "tool"}
+	cmd.AddSubcommand(sub)
+	runner := clapr.NewParser(cmd, syn)
+	result, err := runner.Parse(argv)
+
+	if err != nil {
+		t.Fail()
+		t.Logf("%s: syntax: %s, errored: %v", name, getSynName(syn), err)
+
+		return
+	}
+
+	if ran || val != "" || quiet {
+		t.Fail()
+		t.Errorf("%s: syntax: %s, ran or bound while parsing", name, getSynName(syn))
+	}
+
+	if len(result.Commands) != 2 || result.Commands[1].Command != sub || result.Commands[1].Path != "tool sub" {
+		t.Fail()
+		t.Errorf("%s: syntax: %s, unexpected commands: %v", name, getSynName(syn), result.Commands)
+
+		return
+	}
+
+	parsed := result.Commands[1]
+
+	if !reflect.DeepEqual(parsed.Operands, []string{"bar"}) || len(parsed.Options) != 2 {
+		t.Fail()
+		t.Errorf("%s: syntax: %s, unexpected operands: %v, options: %v", name, getSynName(syn), parsed.Operands, parsed.Options)
+
+		return
+	}
+
+	if opt := parsed.Options[0]; opt.Arg.Name != "name" || opt.Value != "foo" || opt.Source != clapr.SourceArgs {
+		t.Fail()
+		t.Errorf("%s: syntax: %s, unexpected option: %v", name, getSynName(syn), opt)
+	}
+
+	if err := runner.Execute(context.Background(), result); err != nil || !ran || val != "foo" || !quiet {
+		t.Fail()
+		t.Errorf("%s: syntax: %s, did not execute: %v", name, getSynName(syn), err)
+	}
+}
+
+func shouldParseAliasSource(t *testing.T, name string, syn clapr.ArgSyntax) {
+	sub := &clapr.Command{
+		Args: []*clapr.Arg{
+			{Binder: clapr.NewBoolArgBinder(new(bool)), Name: "quiet", ShortName: 'q'},
+			{Binder: clapr.NewBoolArgBinder(new(bool)), Name: "verbose", ShortName: 'v'},
+		},
+		Name: "checkout",
+	}
+	cmd := &clapr.Command{}
+	cmd.AddSubcommand(sub)
+	runner, ok := clapr.NewRunner(cmd, syn, clapr.WithAliases(map[string]string{"co": "checkout -q"})).(clapr.Parser)
+
+	if !ok {
+		t.Fail()
+		t.Logf("%s: syntax: %s, runner does not satisfy Parser", name, getSynName(syn))
+
+		return
+	}
+
+	result, err := runner.Parse([]string{"co", "-v"})
+
+	if err != nil || len(result.Commands) != 2 || len(result.Commands[1].Options) != 2 {
+		t.Fail()
+		t.Logf("%s: syntax: %s, unexpected result: %v, err: %v", name, getSynName(syn), result, err)
+
+		return
+	}
+
+	opts := result.Commands[1].Options
+
+	if opts[0].Source != clapr.SourceAlias || opts[1].Source != clapr.SourceArgs {
+		t.Fail()
+		t.Errorf("%s: syntax: %s, unexpected sources: %s, %s", name, getSynName(syn), opts[0].Source, opts[1].Source)
+	}
+}
+
+func shouldErrExecuteForeignResult(t *testing.T, name string, syn clapr.ArgSyntax) {
+	cmd := &clapr.Command{}
+	result, err := clapr.NewParser(cmd, syn).Parse(nil)
+
+	if err != nil {
+		t.Fail()
+		t.Logf("%s: syntax: %s, errored: %v", name, getSynName(syn), err)
+
+		return
+	}
+
+	if err := clapr.NewParser(cmd, syn).Execute(context.Background(), result); err == nil {
+		t.Fail()
+		t.Errorf("%s: syntax: %s, did not error for result from other runner", name, getSynName(syn))
+	}
+
+	if err := clapr.NewParser(cmd, syn).Execute(context.Background(), &clapr.ParseResult{}); err == nil {
+		t.Fail()
+		t.Errorf("%s: syntax: %s, did not error for empty result", name, getSynName(syn))
+	}
+}
+
+func shouldErrExecutingResultTwice(t *testing.T, name string, syn clapr.ArgSyntax) {
+	argv := []string{"--name=foo"}
+
+	if syn == clapr.POSIX {
+		argv = []string{"-n", "foo"}
+	}
+
+	var names []string
+	runs := 0
+	cmd := &clapr.Command{
+		Args: []*clapr.Arg{{Binder: clapr.NewStringListArgBinder(&names), Name: "name", ShortName: 'n'}},
+		Run: func(context.Context, []string) {
+			runs++
+		},
+	}
+	runner := clapr.NewParser(cmd, syn)
+	result, err := runner.Parse(argv)
+
+	if err == nil {
+		err = runner.Execute(context.Background(), result)
+	}
+
+	if err != nil {
+		t.Fail()
+		t.Logf("%s: syntax: %s, errored: %v", name, getSynName(syn), err)
+
+		return
+	}
+
+	if err := runner.Execute(context.Background(), result); err == nil || runs != 1 || len(names) != 1 {
+		t.Fail()
+		t.Errorf("%s: syntax: %s, runs: %d, names: %v, err: %v", name, getSynName(syn), runs, names, err)
+	}
+}
+
+func shouldErrExplainInvalidValue(t *testing.T, name string, syn clapr.ArgSyntax) {
+	argv := []string{"--count=foo"}
+
+	if syn == clapr.POSIX {
+		argv = []string{"-c", "foo"}
+	}
+
+	count := 1
+	cmd := &clapr.Command{
+		Args: []*clapr.Arg{{Binder: clapr.NewIntArgBinder(&count), Name: "count", ShortName: 'c'}},
+	}
+	var out strings.Builder
+	runner := clapr.NewRunner(cmd, syn, clapr.WithArgs(argv...), clapr.WithExplain(), clapr.WithIO(clapr.IOStreams{Out: &out}))
+	err := runner.Run(context.Background())
+
+	if clapr.ExitCode(err) != clapr.ExitUsage || out.Len() > 0 || count != 1 {
+		t.Fail()
+		t.Logf("%s: syntax: %s, count: %d, out: %q, err: %v", name, getSynName(syn), count, out.String(), err)
+	}
+}
+
+func shouldExplainWithoutRunning(t *testing.T, name string, syn clapr.ArgSyntax) {
+	argv := []string{"dp", "--region=eu", "--", "app"}
+	expect := `1. tool (not run)
+2. tool deploy
+    -f (alias)
+    --region = "eu" (command line)
+    operands: "app"
+`
+
+	if syn == clapr.POSIX {
+		argv = []string{"dp", "-r", "eu", "--", "app"}
+		expect = strings.Replace(expect, "--region", "-r", 1)
+	}
+
+	region := ""
+	ran := false
+	deploy := &clapr.Command{
+		Args: []*clapr.Arg{
+			{Binder: clapr.NewStringArgBinder(&region), Name: "region", ShortName: 'r'},
+			{Binder: clapr.NewBoolArgBinder(new(bool)), Name: "force", ShortName: 'f'},
+		},
+		Name: "deploy",
+		Run: func(context.Context, []string) {
+			ran = true
+		},
+	}
+	cmd := &clapr.Command{Name: "tool"}
+	cmd.AddSubcommand(deploy)
+	var out strings.Builder
+	runner := clapr.NewRunner(
+		cmd,
+		syn,
+		clapr.WithAliases(map[string]string{"dp": "deploy -f"}),
+		clapr.WithArgs(argv...),
+		clapr.WithExplain(),
+		clapr.WithOutput(&out),
+	)
+
+	if err := runner.Run(context.Background()); err != nil || ran || region != "" {
+		t.Fail()
+		t.Logf("%s: syntax: %s, ran: %t, region: %s, err: %v", name, getSynName(syn), ran, region, err)
+	}
+
+	if out.String() != expect {
+		t.Fail()
+		t.Errorf("%s: syntax: %s, expected: %q got: %q", name, getSynName(syn), expect, out.String())
+	}
+}
+
+func shouldExplainWithFlag(t *testing.T, name string, syn clapr.ArgSyntax) {
+	if syn == clapr.POSIX {
+		return
+	}
+
+	ran := false
+	cmd := &clapr.Command{Name: "tool"}
+	cmd.AddSubcommand(getChainCmd("build", &[]string{}, nil), getChainCmd("lint", &[]string{}, nil))
+	cmd.Find("lint").Run = func(context.Context, []string) { ran = true }
+	var out strings.Builder
+	runner := clapr.NewRunner(
+		cmd,
+		syn,
+		clapr.WithArgs("build", "lint", "--explain"),
+		clapr.WithChaining(clapr.ChainParallel),
+		clapr.WithExplainFlag(),
+		clapr.WithOutput(&out),
+	)
+	expect := "1. tool (not run)\n2. tool build (parallel)\n3. tool lint (parallel)\n"
+
+	if err := runner.Run(context.Background()); err != nil || ran || out.String() != expect {
+		t.Fail()
+		t.Logf("%s: syntax: %s, ran: %t, output: %q, err: %v", name, getSynName(syn), ran, out.String(), err)
+	}
+}
+
+func shouldTraceRules(t *testing.T, name string, syn clapr.ArgSyntax) {
+	argv := []string{"sub", "--name", "foo"}
+	expect := []string{
+		"tool: sub: subcommand accept",
+		"tool sub: --name: gnuTerminated skip",
+		"tool sub: --name: posixTerminated skip",
+		"tool sub: --name: validGnuOpt skip",
+		"tool sub: --name: gnuOpt accept",
+		"tool sub: foo: gnuTerminated skip",
+		"tool sub: foo: posixTerminated skip",
+		"tool sub: foo: validGnuOpt skip",
+		"tool sub: foo: gnuOpt skip",
+		"tool sub: foo: posixOperand skip",
+		"tool sub: foo: gnuOptArg accept",
+	}
+
+	if syn == clapr.POSIX {
+		argv = []string{"sub", "-n", "foo"}
+		expect = []string{
+			"tool: sub: subcommand accept",
+			"tool sub: -n: posixTerminated skip",
+			"tool sub: -n: validPosixOpt skip",
+			"tool sub: -n: posixOpt accept",
+			"tool sub: foo: posixTerminated skip",
+			"tool sub: foo: validPosixOpt skip",
+			"tool sub: foo: posixOpt skip",
+			"tool sub: foo: posixOperand skip",
+			"tool sub: foo: posixOptArg accept",
+		}
+	}
+
+	var got []string
+	cmd := &clapr.Command{Name: "tool"}
+	cmd.AddSubcommand(&clapr.Command{
+		Args: []*clapr.Arg{{Binder: clapr.NewStringArgBinder(new(string)), Name: "name", Required: true, ShortName: 'n'}},
+		Name: "sub",
+	})
+	runner := clapr.NewRunner(cmd, syn, clapr.WithArgs(argv...), clapr.WithTrace(func(event clapr.TraceEvent) {
+		got = append(got, event.String())
+	}))
+
+	if err := runner.Run(context.Background()); err != nil || !reflect.DeepEqual(got, expect) {
+		t.Fail()
+		t.Logf("%s: syntax: %s, expected: %v got: %v, err: %v", name, getSynName(syn), expect, got, err)
+	}
+}
+
+func shouldTraceFromEnv(t *testing.T, name string, syn clapr.ArgSyntax) {
+	var out strings.Builder
+	cmd := &clapr.Command{Name: "tool"}
+	runner := clapr.NewRunner(
+		cmd,
+		syn,
+		clapr.WithArgs("bogus"),
+		clapr.WithEnv([]string{"CLAPR_TRACE=1"}),
+		clapr.WithOutput(&out),
+	)
+	expect := "clapr: trace: tool: bogus: validGnuOpt reject: invalid option: bogus\n"
+
+	if syn == clapr.POSIX {
+		expect = "clapr: trace: tool: bogus: validPosixOpt reject: invalid option: bogus\n"
+	}
+
+	if err := runner.Run(context.Background()); err == nil || !strings.HasSuffix(out.String(), expect) {
+		t.Fail()
+		t.Logf("%s: syntax: %s, unexpected trace: %q, err: %v", name, getSynName(syn), out.String(), err)
+	}
+
+	out.Reset()
+	runner = clapr.NewRunner(cmd, syn, clapr.WithArgs("bogus"), clapr.WithEnv([]string{"CLAPR_TRACE=0"}), clapr.WithOutput(&out))
+
+	if _ = runner.Run(context.Background()); out.Len() > 0 {
+		t.Fail()
+		t.Errorf("%s: syntax: %s, traced when disabled: %q", name, getSynName(syn), out.String())
+	}
+}
+
+func shouldErrVersionArg(t *testing.T, name string, syn clapr.ArgSyntax) {
+	args := [][]string{{"-V"}, {"--version"}}
+
+	if syn == clapr.POSIX {
+		args = args[:1]
+	}
+
+	for _, argv := range args {
+		ran := false
+		cmd := &clapr.Command{
+			Name: "tool",
+			Run: func(context.Context, []string) {
+				ran = true
+			},
+			Version: "1.2.3",
+		}
+		err := clapr.NewRunner(cmd, syn, clapr.WithArgs(argv...)).Run(context.Background())
+		var versionErr *clapr.ErrVersion
+
+		if !errors.As(err, &versionErr) || versionErr.Version != "tool 1.2.3" || ran || clapr.ExitCode(err) != clapr.ExitOK {
+			t.Fail()
+			t.Logf("%s: syntax: %s, args: %v, ran: %t, unexpected error: %v", name, getSynName(syn), argv, ran, err)
+		}
+	}
+}
+
+func shouldErrBuildInfoVersion(t *testing.T, name string, syn clapr.ArgSyntax) {
+	runner := clapr.NewRunner(&clapr.Command{Name: "tool"}, syn, clapr.WithArgs("-V"), clapr.WithVersionFlag())
+	err := runner.Run(context.Background())
+	var versionErr *clapr.ErrVersion
+
+	if !errors.As(err, &versionErr) {
+		t.Fail()
+		t.Logf("%s: syntax: %s, unexpected error: %v", name, getSynName(syn), err)
+
+		return
+	}
+
+	if !strings.HasPrefix(versionErr.Version, "tool") || !strings.HasSuffix(versionErr.Version, "\ngo: "+runtime.Version()) {
+		t.Fail()
+		t.Errorf("%s: syntax: %s, unexpected version: %q", name, getSynName(syn), versionErr.Version)
+	}
+}
+
+func shouldNotAddVersionArg(t *testing.T, name string, syn clapr.ArgSyntax) {
+	runner := clapr.NewRunner(&clapr.Command{Name: "tool"}, syn, clapr.WithArgs("-V"))
+
+	if err := runner.Run(context.Background()); clapr.ExitCode(err) != clapr.ExitUsage {
+		t.Fail()
+		t.Logf("%s: syntax: %s, unexpected error: %v", name, getSynName(syn), err)
+	}
+}
+
+func shouldPreferCmdVersionOpt(t *testing.T, name string, syn clapr.ArgSyntax) {
+	verbose := false
+	cmd := &clapr.Command{
+		Args:    []*clapr.Arg{{Binder: clapr.NewBoolArgBinder(&verbose), Name: "verbose", ShortName: 'V'}},
+		Version: "1.2.3",
+	}
+	runner := clapr.NewRunner(cmd, syn, clapr.WithArgs("-V"))
+
+	if err := runner.Run(context.Background()); err != nil || !verbose {
+		t.Fail()
+		t.Logf("%s: syntax: %s, verbose: %t, err: %v", name, getSynName(syn), verbose, err)
+	}
+}
+
+func shouldWriteProfiles(t *testing.T, name string, syn clapr.ArgSyntax) {
+	if syn == clapr.POSIX {
+		return
+	}
+
+	dir, err := ioutil.TempDir("", "clapr")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer os.RemoveAll(dir)
+
+	files := []string{filepath.Join(dir, "cpu"), filepath.Join(dir, "mem"), filepath.Join(dir, "trace")}
+	ran := false
+	cmd := &clapr.Command{}
+	cmd.AddSubcommand(&clapr.Command{
+		Name: "sub",
+		Run: func(context.Context, []string) {
+			ran = true
+		},
+	})
+	runner := clapr.NewRunner(cmd, syn, clapr.WithProfilingFlags(), clapr.WithArgs(
+		"--cpuprofile="+files[0],
+		"sub",
+		"--memprofile="+files[1],
+		"--trace="+files[2],
+	))
+
+	if err := runner.Run(context.Background()); err != nil || !ran {
+		t.Fail()
+		t.Logf("%s: syntax: %s, ran: %t, err: %v", name, getSynName(syn), ran, err)
+	}
+
+	for _, file := range files {
+		if info, err := os.Stat(file); err != nil || info.Size() == 0 {
+			t.Fail()
+			t.Errorf("%s: syntax: %s, profile not written: %s", name, getSynName(syn), file)
+		}
+	}
+}
+
+func shouldServeProfiles(t *testing.T, name string, syn clapr.ArgSyntax) {
+	if syn == clapr.POSIX {
+		return
+	}
+
+	var out strings.Builder
+	var body []byte
+	cmd := &clapr.Command{
+		RunE: func(context.Context, []string) error {
+			url := strings.TrimSpace(strings.TrimPrefix(out.String(), "clapr: serving profiles at "))
+			resp, err := http.Get(url + "goroutine?debug=1")
+
+			if err != nil {
+				return err
+			}
+
+			defer resp.Body.Close()
+			body, err = ioutil.ReadAll(resp.Body)
+
+			return err
+		},
+	}
+	runner := clapr.NewRunner(cmd, syn, clapr.WithArgs("--pprof-addr=127.0.0.1:0"), clapr.WithOutput(&out), clapr.WithProfilingFlags())
+
+	if err := runner.Run(context.Background()); err != nil || !strings.Contains(string(body), "goroutine profile:") {
+		t.Fail()
+		t.Logf("%s: syntax: %s, unexpected profile: %q, err: %v", name, getSynName(syn), body, err)
+	}
+}
+
+func shouldHideProfilingFlags(t *testing.T, name string, syn clapr.ArgSyntax) {
+	cmd := &clapr.Command{
+		Args: []*clapr.Arg{{Hidden: true, Name: "secret"}},
+		Name: "tool",
+	}
+	runner := clapr.NewRunner(cmd, syn, clapr.WithArgs("-h"), clapr.WithProfilingFlags())
+	err := runner.Run(context.Background())
+
+	if err == nil || err.Error() != "Usage:\n    tool" {
+		t.Fail()
+		t.Logf("%s: syntax: %s, unexpected help: %q", name, getSynName(syn), err)
+	}
+}
+
+func shouldParseManyOptions(t *testing.T, name string, syn clapr.ArgSyntax) {
+	const count = 20
+	vals := make([]bool, count)
+	args := make([]*clapr.Arg, count)
+	argv := []string{"sub"}
+
+	for i := range args {
+		args[i] = &clapr.Arg{Binder: clapr.NewBoolArgBinder(&vals[i]), Name: fmt.Sprintf("opt%d", i), ShortName: rune('a' + i)}
+
+		if syn == clapr.POSIX || i < count/2 {
+			argv = append(argv, fmt.Sprintf("-%c", 'a'+i))
+		} else {
+			argv = append(argv, fmt.Sprintf("--opt%d", i))
+		}
+	}
+
+	cmd := &clapr.Command{}
+	cmd.AddSubcommand(&clapr.Command{Args: args, Name: "sub"})
+	runner := clapr.NewRunner(cmd, syn, clapr.WithArgs(argv...))
+
+	if err := runner.Run(context.Background()); err != nil {
+		t.Fail()
+		t.Logf("%s: syntax: %s, errored: %v", name, getSynName(syn), err)
+	}
+
+	for i, val := range vals {
+		if !val {
+			t.Fail()
+			t.Errorf("%s: syntax: %s, opt%d not bound", name, getSynName(syn), i)
+		}
+	}
+}
+
+func shouldStartParallelSiblingsAfterFailure(t *testing.T, name string, syn clapr.ArgSyntax) {
+	const runs = 50
+	var started int32
+	failerr := fmt.Errorf("should error")
+	cmd := &clapr.Command{}
+	cmd.AddSubcommand(&clapr.Command{
+		Name: "lint",
+		Run: func(context.Context, []string) {
+			atomic.AddInt32(&started, 1)
+		},
+	}, &clapr.Command{
+		Name: "fail",
+		RunE: func(context.Context, []string) error {
+			return failerr
+		},
+	})
+
+	for i := 0; i < runs; i++ {
+		runner := clapr.NewRunner(cmd, syn, clapr.WithArgs("lint", "fail"), clapr.WithChaining(clapr.ChainParallel))
+
+		if err := runner.Run(context.Background()); !errors.Is(err, failerr) {
+			t.Fail()
+			t.Logf("%s: syntax: %s, unexpected error: %v", name, getSynName(syn), err)
+		}
+	}
+
+	if started != runs {
+		t.Fail()
+		t.Errorf("%s: syntax: %s, lint started %d of %d times", name, getSynName(syn), started, runs)
+	}
+}
+
+func shouldHelpOnlyAcceptedShortNames(t *testing.T, name string, syn clapr.ArgSyntax) {
+	cmd := &clapr.Command{
+		Args: []*clapr.Arg{
+			{Binder: clapr.NewBoolArgBinder(new(bool)), Name: "long"},
+			{Binder: clapr.NewBoolArgBinder(new(bool)), Name: "s"},
+		},
+		Name: "test",
+	}
+	runner := clapr.NewRunner(cmd, syn, clapr.WithArgs("-h"), clapr.WithExplainFlag(), clapr.WithTimeoutFlag())
+	err := runner.Run(context.Background())
+	help := fmt.Sprint(err)
+
+	for _, short := range []string{"-l", "-e", "-t"} {
+		if strings.Contains(help, short+",") || strings.Contains(help, short+" ") {
+			t.Fail()
+			t.Logf("%s: syntax: %s, help shows unaccepted short name %s:\n%s", name, getSynName(syn), short, help)
+		}
+	}
+
+	if !strings.Contains(help, "-s") {
+		t.Fail()
+		t.Logf("%s: syntax: %s, help missing single rune name:\n%s", name, getSynName(syn), help)
+	}
+
+	if syn == clapr.GNU && (!strings.Contains(help, "--explain") || !strings.Contains(help, "--timeout")) {
+		t.Fail()
+		t.Logf("%s: syntax: %s, help missing built-in options:\n%s", name, getSynName(syn), help)
+	}
+}
+
+func getChainCmd(name string, ran *[]string, err error) *clapr.Command {
+	return &clapr.Command{
+		Name: name,
+		RunE: func(context.Context, []string) error {
+			*ran = append(*ran, name)
+
+			return err
+		},
+	}
+}
+
 func getSynName(syn clapr.ArgSyntax) string {
 	if syn == clapr.GNU {
 		return "GNU"
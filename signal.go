@@ -0,0 +1,71 @@
+// Copyright 2020 Stephen Buckler. All rights reserved.
+// Use of this source code is governed by a MIT license
+// that can be found in the LICENSE file.
+
+package clapr
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+var shutdownSignals = []os.Signal{os.Interrupt, syscall.SIGTERM}
+
+func (r *runner) notifyContext(parent context.Context) (context.Context, func()) {
+	ctx, cancel := context.WithCancel(parent)
+	sigs := make(chan os.Signal, 1)
+	stopped := make(chan struct{})
+	finished := make(chan struct{})
+
+	signal.Notify(sigs, shutdownSignals...)
+
+	go func() {
+		defer close(finished)
+
+		select {
+		case <-sigs:
+		case <-stopped:
+			return
+		}
+
+		cancel()
+		cleaned := make(chan struct{})
+
+		go func() {
+			defer close(cleaned)
+
+			for _, fn := range r.cleanup {
+				fn()
+			}
+		}()
+
+		select {
+		case sig := <-sigs:
+			os.Exit(signalExitCode(sig))
+		case <-cleaned:
+		}
+
+		select {
+		case sig := <-sigs:
+			os.Exit(signalExitCode(sig))
+		case <-stopped:
+		}
+	}()
+
+	return ctx, func() {
+		close(stopped)
+		<-finished
+		signal.Stop(sigs)
+		cancel()
+	}
+}
+
+func signalExitCode(sig os.Signal) int {
+	if s, ok := sig.(syscall.Signal); ok {
+		return 128 + int(s)
+	}
+
+	return 1
+}
@@ -0,0 +1,129 @@
+// Copyright 2020 Stephen Buckler. All rights reserved.
+// Use of this source code is governed by a MIT license
+// that can be found in the LICENSE file.
+
+//go:build !windows
+// +build !windows
+
+package clapr_test
+
+import (
+	"context"
+	"errors"
+	"github.com/sebuckler/clapr"
+	"os"
+	"os/exec"
+	"syscall"
+	"testing"
+	"time"
+)
+
+type testsignalfn func(t *testing.T, name string)
+
+func TestRunner_Run_Signals(t *testing.T) {
+	testCases := map[string]testsignalfn{
+		"should cancel context on signal":  shouldCancelOnSignal,
+		"should not cancel without signal": shouldNotCancelWithoutSignal,
+		"should exit on second signal":     shouldExitOnSecondSignal,
+	}
+
+	for name, test := range testCases {
+		test(t, name)
+	}
+}
+
+func shouldCancelOnSignal(t *testing.T, name string) {
+	os.Args = []string{"test"}
+	var cleaned []int
+	cmd := &clapr.Command{
+		RunE: func(ctx context.Context, _ []string) error {
+			_ = syscall.Kill(os.Getpid(), syscall.SIGINT)
+
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(5 * time.Second):
+				return nil
+			}
+		},
+	}
+	cleanup := func(i int) func() {
+		return func() {
+			cleaned = append(cleaned, i)
+		}
+	}
+	runner := clapr.NewRunner(cmd, clapr.GNU, clapr.WithSignalHandling(cleanup(1), cleanup(2)))
+
+	if err := runner.Run(context.Background()); !errors.Is(err, context.Canceled) {
+		t.Fail()
+		t.Logf("%s: unexpected error: %v", name, err)
+	}
+
+	if len(cleaned) != 2 || cleaned[0] != 1 || cleaned[1] != 2 {
+		t.Fail()
+		t.Errorf("%s: cleanup not run in order: %v", name, cleaned)
+	}
+}
+
+func shouldNotCancelWithoutSignal(t *testing.T, name string) {
+	os.Args = []string{"test"}
+	cleaned := false
+	var runctx context.Context
+	cmd := &clapr.Command{
+		Run: func(ctx context.Context, _ []string) {
+			runctx = ctx
+		},
+	}
+	runner := clapr.NewRunner(cmd, clapr.GNU, clapr.WithSignalHandling(func() { cleaned = true }))
+
+	if err := runner.Run(context.Background()); err != nil || cleaned {
+		t.Fail()
+		t.Logf("%s: cleaned: %t, err: %v", name, cleaned, err)
+	}
+
+	if runctx == nil || runctx.Err() == nil {
+		t.Fail()
+		t.Errorf("%s: context not canceled after run", name)
+	}
+}
+
+func shouldExitOnSecondSignal(t *testing.T, name string) {
+	if os.Getenv("CLAPR_TEST_SIGNAL_EXIT") == "1" {
+		runSecondSignal()
+
+		return
+	}
+
+	bin, err := os.Executable()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	proc := exec.Command(bin, "-test.run=TestRunner_Run_Signals")
+	proc.Env = append(os.Environ(), "CLAPR_TEST_SIGNAL_EXIT=1")
+	err = proc.Run()
+	var exitErr *exec.ExitError
+
+	if !errors.As(err, &exitErr) || exitErr.ExitCode() != 128+int(syscall.SIGINT) {
+		t.Fail()
+		t.Logf("%s: unexpected exit: %v", name, err)
+	}
+}
+
+func runSecondSignal() {
+	os.Args = []string{"test"}
+	cmd := &clapr.Command{
+		Run: func(ctx context.Context, _ []string) {
+			_ = syscall.Kill(os.Getpid(), syscall.SIGINT)
+			<-ctx.Done()
+		},
+	}
+	cleanup := func() {
+		_ = syscall.Kill(os.Getpid(), syscall.SIGINT)
+		time.Sleep(5 * time.Second)
+	}
+	runner := clapr.NewRunner(cmd, clapr.GNU, clapr.WithSignalHandling(cleanup))
+	_ = runner.Run(context.Background())
+	os.Exit(0)
+}
@@ -43,10 +43,12 @@ func (h *FakeHelper) Help(syn clapr.ArgSyntax) string {
 
 /*
 FakeRunner is used for mocking a Runner in unit tests. It satisfies
-the Runner interface.
+both the Runner and Parser interfaces.
 */
 type FakeRunner struct {
-	FakeRun func(ctx context.Context) error // Assign any implementation necessary for a given test
+	FakeExecute func(ctx context.Context, result *clapr.ParseResult) error // Assign any implementation necessary for a given test
+	FakeParse   func(argv []string) (*clapr.ParseResult, error)            // Assign any implementation necessary for a given test
+	FakeRun     func(ctx context.Context) error                            // Assign any implementation necessary for a given test
 }
 
 /*
@@ -56,3 +58,19 @@ interception in unit tests.
 func (f *FakeRunner) Run(ctx context.Context) error {
 	return f.FakeRun(ctx)
 }
+
+/*
+Parse internally calls the FakeParse property function for easy method
+interception in unit tests.
+*/
+func (f *FakeRunner) Parse(argv []string) (*clapr.ParseResult, error) {
+	return f.FakeParse(argv)
+}
+
+/*
+Execute internally calls the FakeExecute property function for easy
+method interception in unit tests.
+*/
+func (f *FakeRunner) Execute(ctx context.Context, result *clapr.ParseResult) error {
+	return f.FakeExecute(ctx, result)
+}
@@ -56,3 +56,35 @@ func TestFakeRunner_Run(t *testing.T) {
 		t.Errorf("should call fake run: did not execute")
 	}
 }
+
+func TestFakeRunner_Parse(t *testing.T) {
+	ran := false
+	fake := testclapr.FakeRunner{
+		FakeParse: func([]string) (*clapr.ParseResult, error) {
+			ran = true
+			return nil, nil
+		},
+	}
+	_, _ = fake.Parse([]string{"foo"})
+
+	if !ran {
+		t.Fail()
+		t.Errorf("should call fake parse: did not execute")
+	}
+}
+
+func TestFakeRunner_Execute(t *testing.T) {
+	ran := false
+	fake := testclapr.FakeRunner{
+		FakeExecute: func(context.Context, *clapr.ParseResult) error {
+			ran = true
+			return nil
+		},
+	}
+	_ = fake.Execute(context.Background(), &clapr.ParseResult{})
+
+	if !ran {
+		t.Fail()
+		t.Errorf("should call fake execute: did not execute")
+	}
+}
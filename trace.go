@@ -0,0 +1,100 @@
+// Copyright 2020 Stephen Buckler. All rights reserved.
+// Use of this source code is governed by a MIT license
+// that can be found in the LICENSE file.
+
+package clapr
+
+import (
+	"errors"
+	"fmt"
+	"io"
+)
+
+/*
+TraceEvent describes a single parsing decision. An argument matching a
+subcommand is traced with the "subcommand" rule. Any other argument is
+passed through the parsing rules of the ArgSyntax in order until one
+accepts or rejects it, and an event is traced for every rule tried.
+*/
+type TraceEvent struct {
+	Command  *Command      // Command the argument was parsed for
+	Decision TraceDecision // What the rule decided
+	Err      error         // Error the rule rejected the argument with
+	Rule     string        // Name of the parsing rule
+	Token    string        // Argument as the rule received it
+}
+
+/*
+TraceDecision is the outcome of a parsing rule for a single argument.
+*/
+type TraceDecision int
+
+const (
+	TraceSkip   TraceDecision = iota // Rule does not apply, the next rule is tried
+	TraceAccept                      // Rule consumed the argument
+	TraceReject                      // Rule failed parsing with an error
+)
+
+/*
+String returns the name of the decision.
+*/
+func (d TraceDecision) String() string {
+	switch d {
+	case TraceSkip:
+		return "skip"
+	case TraceAccept:
+		return "accept"
+	case TraceReject:
+		return "reject"
+	}
+
+	return "unknown"
+}
+
+/*
+String formats the event as a single line, such as
+"tool deploy: --region: gnuOpt accept".
+*/
+func (e TraceEvent) String() string {
+	s := fmt.Sprintf("%s: %s: %s %s", e.Command.Path(), e.Token, e.Rule, e.Decision)
+
+	if e.Err != nil {
+		s += fmt.Sprintf(": %v", e.Err)
+	}
+
+	return s
+}
+
+func (p *parser) traceWriter() io.Writer {
+	if val := p.getenv("CLAPR_TRACE"); val != "" && val != "0" && val != "false" {
+		return p.streams.Err
+	}
+
+	return nil
+}
+
+func (p *parser) trace(cmd *Command, rule string, token string, accepted bool, err error) {
+	if p.tracefn == nil && p.tracew == nil {
+		return
+	}
+
+	event := TraceEvent{Command: cmd, Rule: rule, Token: token}
+	var errterm *errTerm
+
+	switch {
+	case errors.As(err, &errterm):
+		event.Decision = TraceAccept
+	case err != nil:
+		event.Decision, event.Err = TraceReject, err
+	case accepted:
+		event.Decision = TraceAccept
+	}
+
+	if p.tracefn != nil {
+		p.tracefn(event)
+	}
+
+	if p.tracew != nil {
+		fmt.Fprintf(p.tracew, "clapr: trace: %s\n", event)
+	}
+}
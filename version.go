@@ -0,0 +1,47 @@
+// Copyright 2020 Stephen Buckler. All rights reserved.
+// Use of this source code is governed by a MIT license
+// that can be found in the LICENSE file.
+
+package clapr
+
+import (
+	"fmt"
+	"runtime/debug"
+	"strings"
+)
+
+func (p *parser) versionText() string {
+	var lines []string
+	version := p.root.Version
+	info, ok := debug.ReadBuildInfo()
+
+	if version == "" && ok {
+		version = info.Main.Version
+	}
+
+	lines = append(lines, strings.TrimSpace(p.rootName()+" "+version))
+
+	if p.root.Version != "" || !ok {
+		return lines[0]
+	}
+
+	settings := map[string]string{}
+
+	for _, s := range info.Settings {
+		settings[s.Key] = s.Value
+	}
+
+	if rev := settings["vcs.revision"]; rev != "" {
+		if settings["vcs.modified"] == "true" {
+			rev += " (modified)"
+		}
+
+		lines = append(lines, fmt.Sprintf("revision: %s", rev))
+	}
+
+	if info.GoVersion != "" {
+		lines = append(lines, fmt.Sprintf("go: %s", info.GoVersion))
+	}
+
+	return strings.Join(lines, "\n")
+}